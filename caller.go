@@ -0,0 +1,91 @@
+package gelf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SplitCaller set sending of entry caller as _file, _line and _function fields instead of _caller field. File is
+// formatted by EncodeCaller, e.g. pkg/file.go by zapcore.ShortCallerEncoder or full path by
+// zapcore.FullCallerEncoder.
+func SplitCaller(value bool) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.splitCaller = value
+		return nil
+	})
+}
+
+// CallerFileLine set sending of entry caller as GELF 1.0 file and line fields, always set in GELF 1.0 mode.
+func CallerFileLine(value bool) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.callerFileLine = value
+		return nil
+	})
+}
+
+// appendCaller appends split caller fields.
+func (w *wrappedCore) appendCaller(escaped []zapcore.Field, caller zapcore.EntryCaller) []zapcore.Field {
+	var fileLine = w.conf.callerFileLine || w.conf.legacy()
+	if !caller.Defined || (!w.conf.splitCaller && !fileLine) {
+		return escaped
+	}
+
+	var file, line = w.splitCaller(caller)
+	if w.conf.splitCaller {
+		escaped = append(escaped, zap.String("_file", file))
+		if line >= 0 {
+			escaped = append(escaped, zap.Int("_line", line))
+		}
+
+		if caller.Function != "" {
+			escaped = append(escaped, zap.String("_function", caller.Function))
+		}
+	}
+
+	if fileLine {
+		escaped = append(escaped, zap.String("file", file))
+		if line >= 0 {
+			escaped = append(escaped, zap.Int("line", line))
+		}
+	}
+
+	return escaped
+}
+
+// splitCaller returns file and line of caller encoded by EncodeCaller, line is -1 when encoded caller has no line.
+func (w *wrappedCore) splitCaller(caller zapcore.EntryCaller) (string, int) {
+	var encode = w.conf.encoder.EncodeCaller
+	if encode == nil {
+		encode = zapcore.ShortCallerEncoder
+	}
+
+	var (
+		enc   = zapcore.NewMapObjectEncoder()
+		value string
+	)
+
+	_ = enc.AddArray("caller", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		encode(caller, arr)
+		return nil
+	}))
+
+	if values, ok := enc.Fields["caller"].([]interface{}); ok && len(values) > 0 {
+		value = fmt.Sprint(values[0])
+	}
+
+	var i = strings.LastIndexByte(value, ':')
+	if i < 0 {
+		return value, -1
+	}
+
+	var line, err = strconv.Atoi(value[i+1:])
+	if err != nil {
+		return value, -1
+	}
+
+	return value[:i], line
+}
@@ -0,0 +1,56 @@
+package gelf_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestSplitCaller(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.SplitCaller(true),
+		gelf.CallerFileLine(true),
+	)
+	assert.Nil(t, err, "Unexpected error")
+	zap.New(core, zap.AddCaller()).Info("short")
+
+	core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.SplitCaller(true),
+		gelf.EncodeCaller(zapcore.FullCallerEncoder),
+	)
+	assert.Nil(t, err, "Unexpected error")
+	zap.New(core, zap.AddCaller()).Info("full")
+	zap.New(core).Info("no caller")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(3, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Regexp(t, `^[^/]+/caller_test\.go$`, message["_file"])
+	assert.IsType(t, 0.0, message["_line"])
+	assert.Greater(t, message["_line"], 0.0)
+	assert.Equal(t, "github.com/snovichkov/zap-gelf_test.TestSplitCaller", message["_function"])
+	assert.Equal(t, message["_file"], message["file"])
+	assert.Equal(t, message["_line"], message["line"])
+	assert.NotContains(t, message, "_caller")
+
+	message = messages[1]
+	assert.True(t, filepath.IsAbs(message["_file"].(string)))
+	assert.Equal(t, "caller_test.go", filepath.Base(message["_file"].(string)))
+	assert.NotContains(t, message, "file")
+
+	assert.NotContains(t, messages[2], "_file")
+}
@@ -0,0 +1,252 @@
+// Command gelf-listen receives GELF messages over UDP and TCP and prints them.
+//
+// Messages are decompressed, reassembled from chunks and printed as human readable lines or raw JSON, GELF
+// specification violations are reported with "!" prefix:
+//
+//	gelf-listen -udp :12201 -tcp :12201
+//	gelf-listen -udp 127.0.0.1:12201 -tcp "" -json
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/snovichkov/zap-gelf/gelfdecode"
+)
+
+const (
+	// maxDatagramSize maximal UDP datagram size.
+	maxDatagramSize = 65536
+
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorGray   = "\x1b[90m"
+)
+
+type (
+	// printer prints received messages and violations.
+	printer struct {
+		mu      sync.Mutex
+		out     io.Writer
+		raw     bool
+		color   bool
+		decoder *gelfdecode.Decoder
+	}
+)
+
+var (
+	// levelNames syslog level names.
+	levelNames = []string{"EMERG", "ALERT", "CRIT", "ERROR", "WARN", "NOTICE", "INFO", "DEBUG"}
+
+	// levelColors syslog level colors.
+	levelColors = []string{colorRed, colorRed, colorRed, colorRed, colorYellow, colorBlue, colorGreen, colorGray}
+)
+
+func main() {
+	var (
+		err     error
+		flags   = flag.NewFlagSet("gelf-listen", flag.ExitOnError)
+		udpAddr = flags.String("udp", ":12201", "UDP listen address, empty to disable")
+		tcpAddr = flags.String("tcp", ":12201", "TCP listen address, empty to disable")
+		raw     = flags.Bool("json", false, "print raw JSON messages")
+		noColor = flags.Bool("no-color", false, "disable colored output")
+		timeout = flags.Duration("chunk-timeout", gelfdecode.DefaultTimeout, "time to wait for all chunks of message")
+	)
+
+	_ = flags.Parse(os.Args[1:])
+
+	var p = &printer{out: os.Stdout, raw: *raw, color: !*noColor}
+	if p.decoder, err = gelfdecode.NewDecoder(gelfdecode.Timeout(*timeout)); err != nil {
+		fail(err)
+	}
+
+	if *udpAddr != "" {
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket("udp", *udpAddr); err != nil {
+			fail(err)
+		}
+
+		fmt.Fprintf(os.Stderr, "gelf-listen: listening on udp %s\n", conn.LocalAddr())
+		go p.serveUDP(conn)
+	}
+
+	if *tcpAddr != "" {
+		var listener net.Listener
+		if listener, err = net.Listen("tcp", *tcpAddr); err != nil {
+			fail(err)
+		}
+
+		fmt.Fprintf(os.Stderr, "gelf-listen: listening on tcp %s\n", listener.Addr())
+		go p.serveTCP(listener)
+	}
+
+	var signals = make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+}
+
+// serveUDP receives UDP datagrams.
+func (p *printer) serveUDP(conn net.PacketConn) {
+	var buf = make([]byte, maxDatagramSize)
+	for {
+		var n, addr, err = conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		var message gelfdecode.Message
+		if message, err = p.decoder.Decode(buf[:n]); err != nil {
+			p.violation("udp "+addr.String(), err.Error())
+			continue
+		}
+
+		if message != nil {
+			p.message("udp "+addr.String(), message)
+		}
+	}
+}
+
+// serveTCP accepts TCP connections.
+func (p *printer) serveTCP(listener net.Listener) {
+	for {
+		var conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go p.serveConn(conn)
+	}
+}
+
+// serveConn receives null byte delimited messages from TCP connection.
+func (p *printer) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	var (
+		source = "tcp " + conn.RemoteAddr().String()
+		reader = bufio.NewReader(conn)
+	)
+
+	for {
+		var frame, err = reader.ReadBytes(0)
+		if frame = bytes.TrimSuffix(frame, []byte{0}); len(frame) > 0 {
+			if gelfdecode.IsChunk(frame) {
+				p.violation(source, "chunked messages are not allowed over TCP")
+			} else if message, dErr := gelfdecode.DecodePayload(frame); dErr != nil {
+				p.violation(source, dErr.Error())
+			} else {
+				p.message(source, message)
+			}
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				p.violation(source, err.Error())
+			}
+
+			return
+		}
+	}
+}
+
+// message prints message and its violations.
+func (p *printer) message(source string, message gelfdecode.Message) {
+	var violations = gelfdecode.Validate(message)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.raw {
+		var data, _ = json.Marshal(message)
+		fmt.Fprintf(p.out, "%s\n", data)
+	} else {
+		fmt.Fprintln(p.out, p.format(message))
+	}
+
+	for _, violation := range violations {
+		fmt.Fprintln(p.out, p.paint(colorYellow, "! "+source+": "+violation.String()))
+	}
+}
+
+// violation prints protocol violation.
+func (p *printer) violation(source, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Fprintln(p.out, p.paint(colorYellow, "! "+source+": "+reason))
+}
+
+// format formats message as human readable line.
+func (p *printer) format(message gelfdecode.Message) string {
+	var (
+		b     strings.Builder
+		ts    = time.Now()
+		level = 1 // GELF default level
+	)
+
+	if value, ok := message["timestamp"].(float64); ok {
+		var sec, frac = math.Modf(value)
+		ts = time.Unix(int64(sec), int64(frac*float64(time.Second)))
+	}
+
+	if value, ok := message["level"].(float64); ok && value >= 0 && int(value) < len(levelNames) {
+		level = int(value)
+	}
+
+	b.WriteString(p.paint(colorGray, ts.Format("2006-01-02 15:04:05.000")))
+	b.WriteByte(' ')
+	b.WriteString(p.paint(levelColors[level], fmt.Sprintf("%-6s", levelNames[level])))
+	fmt.Fprintf(&b, " %v %v", message["host"], message["short_message"])
+
+	var keys = make([]string, 0, len(message))
+	for key := range message {
+		switch key {
+		case "version", "host", "short_message", "timestamp", "level", "full_message":
+		default:
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		var value, _ = json.Marshal(message[key])
+		fmt.Fprintf(&b, " %s=%s", p.paint(colorBlue, key), value)
+	}
+
+	if value, ok := message["full_message"]; ok {
+		fmt.Fprintf(&b, "\n%v", value)
+	}
+
+	return b.String()
+}
+
+// paint wraps s by color when colors enabled.
+func (p *printer) paint(color, s string) string {
+	if !p.color {
+		return s
+	}
+
+	return color + s + colorReset
+}
+
+// fail print error and exit.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "gelf-listen: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelfdecode"
+)
+
+type (
+	// syncBuffer concurrency safe buffer.
+	syncBuffer struct {
+		mu  sync.Mutex
+		buf bytes.Buffer
+	}
+)
+
+func TestPrinter(t *testing.T) {
+	var (
+		err     error
+		out     = &syncBuffer{}
+		printer = &printer{out: out}
+	)
+
+	printer.decoder, err = gelfdecode.NewDecoder()
+	assert.Nil(t, err, "Unexpected error")
+
+	var conn net.PacketConn
+	conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err, "Unexpected error")
+	defer conn.Close()
+
+	var listener net.Listener
+	listener, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err, "Unexpected error")
+	defer listener.Close()
+
+	go printer.serveUDP(conn)
+	go printer.serveTCP(listener)
+
+	var core, _ = gelf.NewCore(
+		gelf.Addr(conn.LocalAddr().String()),
+		gelf.Host("example.org"),
+		gelf.ChunkSize(gelf.MinChunkSize),
+	)
+	zap.New(core).Warn("over udp", zap.String("user", "alice"), zap.String("payload", strings.Repeat("x", 4096)))
+
+	var tcp net.Conn
+	tcp, err = net.Dial("tcp", listener.Addr().String())
+	assert.Nil(t, err, "Unexpected error")
+
+	_, err = tcp.Write([]byte(`{"version":"1.1","host":"example.org","short_message":"over tcp","nested":{}}` + "\x00"))
+	assert.Nil(t, err, "Unexpected error")
+	assert.Nil(t, tcp.Close(), "Unexpected error")
+
+	var udp, _ = net.Dial("udp", conn.LocalAddr().String())
+	_, _ = udp.Write([]byte{0x1e, 0x0f, 1, 2, 3, 4, 5, 6, 7, 8, 0, 200})
+	udp.Close()
+
+	assert.Eventually(t, func() bool {
+		return strings.Count(out.String(), "\n") >= 5
+	}, time.Second, 10*time.Millisecond)
+
+	var output = out.String()
+	assert.Contains(t, output, "WARN   example.org over udp")
+	assert.Contains(t, output, `_user="alice"`)
+	assert.Contains(t, output, "ALERT  example.org over tcp")
+	assert.Contains(t, output, "nested: additional field should be prefixed by underscore")
+	assert.Contains(t, output, "nested: nested values are not allowed")
+	assert.Contains(t, output, gelfdecode.ErrInvalidChunkCount.Error())
+}
+
+func TestPrinterRaw(t *testing.T) {
+	var (
+		out     = &syncBuffer{}
+		printer = &printer{out: out, raw: true, color: true}
+	)
+
+	printer.message("test", gelfdecode.Message{"version": "1.1", "host": "h", "short_message": "raw"})
+	assert.Equal(t, `{"host":"h","short_message":"raw","version":"1.1"}`+"\n", out.String())
+}
+
+// Write implementation of io.Writer.
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// String implementation of fmt.Stringer.
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
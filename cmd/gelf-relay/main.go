@@ -0,0 +1,436 @@
+// Command gelf-relay receives GELF messages over UDP and forwards them to Graylog over TCP, TLS or HTTP.
+//
+// Chunked and compressed datagrams are reassembled and decompressed, messages are buffered in memory and forwarded
+// in order over single connection. When spool directory is set, messages which could not be forwarded are spooled
+// to file and forwarded after Graylog becomes available, otherwise forwarding is retried until buffer overflows:
+//
+//	gelf-relay -listen 127.0.0.1:12201 -addr graylog:12201 -transport tls -spool /var/spool/gelf-relay
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/atomic"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelfdecode"
+)
+
+const (
+	// maxDatagramSize maximal UDP datagram size.
+	maxDatagramSize = 65536
+
+	// spoolFileName name of spool file in spool directory.
+	spoolFileName = "gelf-relay.spool"
+)
+
+type (
+	// relay forwards received messages to writer.
+	relay struct {
+		decoder     *gelfdecode.Decoder
+		writer      io.Writer
+		spool       *spool
+		retry       time.Duration
+		errorOutput io.Writer
+
+		queue   chan []byte
+		done    chan struct{}
+		stopped chan struct{}
+
+		forwarded atomic.Uint64
+		spooled   atomic.Uint64
+		dropped   atomic.Uint64
+	}
+
+	// spool stores null byte delimited messages in file.
+	spool struct {
+		mu      sync.Mutex
+		path    string
+		size    int64
+		maxSize int64
+	}
+)
+
+var (
+	// errInvalidJSON triggered when received payload is not valid JSON.
+	errInvalidJSON = errors.New("invalid JSON payload")
+
+	// errSpoolFull triggered when spool file exceeds maximal size.
+	errSpoolFull = errors.New("spool is full")
+
+	// errInvalidCA triggered when CA file does not contain PEM certificates.
+	errInvalidCA = errors.New("no certificates found in CA file")
+)
+
+func main() {
+	var (
+		err      error
+		flags    = flag.NewFlagSet("gelf-relay", flag.ExitOnError)
+		listen   = flags.String("listen", ":12201", "UDP listen address")
+		addr     = flags.String("addr", "127.0.0.1:12201", "Graylog GELF input address, URL for http transport")
+		tr       = flags.String("transport", gelf.TransportTCP, "forwarding transport: tcp, tls or http")
+		timeout  = flags.Duration("timeout", gelf.DefaultTimeout, "dial, write and HTTP request timeout")
+		insecure = flags.Bool("insecure", false, "skip TLS certificate verification")
+		caFile   = flags.String("ca", "", "PEM encoded CA certificates file to verify Graylog certificate")
+		buffer   = flags.Int("buffer", 10000, "count of messages buffered in memory")
+		spoolDir = flags.String("spool", "", "spool directory, empty to disable spooling")
+		maxSpool = flags.Int64("spool-size", 64<<20, "maximal spool file size in bytes")
+		retry    = flags.Duration("retry", time.Second, "interval of forwarding retries")
+		chunks   = flags.Duration("chunk-timeout", gelfdecode.DefaultTimeout, "time to wait for all chunks of message")
+	)
+
+	_ = flags.Parse(os.Args[1:])
+
+	var options = []gelf.Option{
+		gelf.Addr(*addr),
+		gelf.Transport(*tr),
+		gelf.Timeout(*timeout),
+	}
+
+	if *insecure || *caFile != "" {
+		var tlsConfig *tls.Config
+		if tlsConfig, err = newTLSConfig(*caFile, *insecure); err != nil {
+			fail(err)
+		}
+
+		options = append(options, gelf.TLSConfig(tlsConfig))
+	}
+
+	var r = &relay{
+		retry:       *retry,
+		errorOutput: os.Stderr,
+		queue:       make(chan []byte, *buffer),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+
+	if r.decoder, err = gelfdecode.NewDecoder(gelfdecode.Timeout(*chunks)); err != nil {
+		fail(err)
+	}
+
+	var w io.WriteCloser
+	if w, err = gelf.NewWriter(options...); err != nil {
+		fail(err)
+	}
+
+	defer w.Close()
+
+	r.writer = w
+	if *spoolDir != "" {
+		if r.spool, err = newSpool(*spoolDir, *maxSpool); err != nil {
+			fail(err)
+		}
+	}
+
+	var conn net.PacketConn
+	if conn, err = net.ListenPacket("udp", *listen); err != nil {
+		fail(err)
+	}
+
+	var signals = make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		conn.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "gelf-relay: forwarding udp %s to %s %s\n", conn.LocalAddr(), *tr, *addr)
+
+	go r.run()
+	r.serve(conn)
+	r.close()
+
+	fmt.Fprintf(
+		os.Stderr, "gelf-relay: forwarded %d, spooled %d, dropped %d messages\n",
+		r.forwarded.Load(), r.spooled.Load(), r.dropped.Load(),
+	)
+}
+
+// newTLSConfig create TLS config with CA certificates from file.
+func newTLSConfig(caFile string, insecure bool) (_ *tls.Config, err error) {
+	var conf = &tls.Config{InsecureSkipVerify: insecure}
+	if caFile == "" {
+		return conf, nil
+	}
+
+	var data []byte
+	if data, err = ioutil.ReadFile(caFile); err != nil {
+		return nil, err
+	}
+
+	conf.RootCAs = x509.NewCertPool()
+	if !conf.RootCAs.AppendCertsFromPEM(data) {
+		return nil, errInvalidCA
+	}
+
+	return conf, nil
+}
+
+// serve receives UDP datagrams until connection is closed.
+func (r *relay) serve(conn net.PacketConn) {
+	var buf = make([]byte, maxDatagramSize)
+	for {
+		var n, addr, err = conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		if err = r.receive(buf[:n]); err != nil {
+			fmt.Fprintf(r.errorOutput, "gelf-relay: udp %s: %v\n", addr, err)
+		}
+	}
+}
+
+// receive reassembles datagram and puts completed message to queue.
+func (r *relay) receive(datagram []byte) error {
+	var payload, err = r.decoder.Reassemble(datagram)
+	if err != nil || payload == nil {
+		return err
+	}
+
+	if !json.Valid(payload) {
+		return errInvalidJSON
+	}
+
+	// payload of not compressed datagram shares receive buffer
+	payload = append([]byte(nil), payload...)
+
+	select {
+	case r.queue <- payload:
+	default:
+		r.overflow(payload)
+	}
+
+	return nil
+}
+
+// run forwards queued messages and replays spool until relay is closed.
+func (r *relay) run() {
+	defer close(r.stopped)
+
+	var ticker = time.NewTicker(r.retry)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case payload, ok := <-r.queue:
+			if !ok {
+				return
+			}
+
+			r.forward(payload)
+		case <-ticker.C:
+			r.replay()
+		}
+	}
+}
+
+// forward sends message, spools message when spool is not empty to keep order of messages.
+func (r *relay) forward(payload []byte) {
+	if r.spool == nil {
+		for r.send(payload) != nil {
+			select {
+			case <-r.done:
+				r.dropped.Inc()
+				return
+			case <-time.After(r.retry):
+			}
+		}
+
+		return
+	}
+
+	if !r.spool.empty() || r.send(payload) != nil {
+		r.overflow(payload)
+	}
+}
+
+// replay forwards spooled messages.
+func (r *relay) replay() {
+	if r.spool == nil || r.spool.empty() {
+		return
+	}
+
+	if err := r.spool.replay(r.send); err != nil {
+		fmt.Fprintf(r.errorOutput, "gelf-relay: replay spool: %v\n", err)
+	}
+}
+
+// send writes message to writer.
+func (r *relay) send(payload []byte) error {
+	if _, err := r.writer.Write(payload); err != nil {
+		fmt.Fprintf(r.errorOutput, "gelf-relay: forward: %v\n", err)
+		return err
+	}
+
+	r.forwarded.Inc()
+
+	return nil
+}
+
+// overflow spools message which could not be queued or forwarded, drops it when spool is disabled or full.
+func (r *relay) overflow(payload []byte) {
+	if r.spool != nil {
+		var err = r.spool.append(payload)
+		if err == nil {
+			r.spooled.Inc()
+			return
+		}
+
+		fmt.Fprintf(r.errorOutput, "gelf-relay: spool: %v\n", err)
+	}
+
+	r.dropped.Inc()
+}
+
+// close stops forwarding after queued messages are forwarded or spooled.
+func (r *relay) close() {
+	close(r.done)
+	close(r.queue)
+	<-r.stopped
+}
+
+// newSpool create spool in directory, messages spooled by previous run are kept.
+func newSpool(dir string, maxSize int64) (_ *spool, err error) {
+	if err = os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+
+	var s = &spool{
+		path:    filepath.Join(dir, spoolFileName),
+		maxSize: maxSize,
+	}
+
+	var info os.FileInfo
+	if info, err = os.Stat(s.path); err == nil {
+		s.size = info.Size()
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// empty returns true when spool has no messages.
+func (s *spool) empty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.size == 0
+}
+
+// append appends message to spool file.
+func (s *spool) append(payload []byte) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size+int64(len(payload))+1 > s.maxSize {
+		return errSpoolFull
+	}
+
+	var file *os.File
+	if file, err = os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640); err != nil {
+		return err
+	}
+
+	var n int
+	n, err = file.Write(append(payload, 0))
+	s.size += int64(n)
+
+	if cErr := file.Close(); err == nil {
+		err = cErr
+	}
+
+	return err
+}
+
+// replay sends spooled messages in order, messages not sent are kept in spool. Lock is held only while spool file is
+// read or rewritten, so messages are appended while spooled messages are sent.
+func (s *spool) replay(send func(payload []byte) error) (err error) {
+	var data []byte
+	s.mu.Lock()
+	data, err = ioutil.ReadFile(s.path)
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	var sent int
+	for sent < len(data) {
+		var payload = data[sent:]
+		if i := bytes.IndexByte(payload, 0); i >= 0 {
+			payload = payload[:i]
+		}
+
+		if send(payload) != nil {
+			break
+		}
+
+		// the last message may have no delimiter
+		if sent += len(payload) + 1; sent > len(data) {
+			sent = len(data)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.discard(int64(sent))
+}
+
+// discard removes first n bytes of spool file, messages appended after replay started are kept.
+func (s *spool) discard(n int64) (err error) {
+	if n == 0 {
+		return nil
+	}
+
+	if n >= s.size {
+		s.size = 0
+		return os.Remove(s.path)
+	}
+
+	var data []byte
+	if data, err = ioutil.ReadFile(s.path); err != nil {
+		return err
+	}
+
+	return s.rewrite(data[n:])
+}
+
+// rewrite replaces spool file content by data.
+func (s *spool) rewrite(data []byte) (err error) {
+	var tmp = s.path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0640); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	s.size = int64(len(data))
+
+	return nil
+}
+
+// fail print error and exit.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "gelf-relay: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelfdecode"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+type (
+	// flakyWriter fails writes while down is set.
+	flakyWriter struct {
+		down     bool
+		payloads []string
+	}
+)
+
+var (
+	// errDown triggered by flakyWriter while down is set.
+	errDown = errors.New("down")
+)
+
+func TestRelay(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var w, err = gelf.NewWriter(
+		gelf.Addr(server.TCPAddr()),
+		gelf.Transport(gelf.TransportTCP),
+	)
+	assert.Nil(t, err, "Unexpected error")
+	defer w.Close()
+
+	var (
+		errorOutput bytes.Buffer
+		r           = newTestRelay(w, nil, 10)
+	)
+
+	r.errorOutput = &errorOutput
+
+	var conn net.PacketConn
+	conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err, "Unexpected error")
+
+	go r.run()
+	var served = make(chan struct{})
+	go func() {
+		r.serve(conn)
+		close(served)
+	}()
+
+	var core, coreErr = gelf.NewCore(
+		gelf.Addr(conn.LocalAddr().String()),
+		gelf.Host("example.org"),
+		gelf.ChunkSize(gelf.MinChunkSize),
+		gelf.CompressionType(gelf.CompressionNone),
+	)
+	assert.Nil(t, coreErr, "Unexpected error")
+
+	var logger = zap.New(core)
+	logger.Info("first")
+	logger.Info(strings.Repeat("x", gelf.MinChunkSize*3))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "first", messages[0]["short_message"])
+	assert.Equal(t, "example.org", messages[0]["host"])
+	assert.Equal(t, strings.Repeat("x", gelf.MinChunkSize*3), messages[1]["short_message"])
+
+	assert.Equal(t, errInvalidJSON, r.receive([]byte("not json")))
+
+	conn.Close()
+	<-served
+	r.close()
+
+	assert.Equal(t, uint64(2), r.forwarded.Load())
+	assert.Equal(t, uint64(0), r.dropped.Load())
+	assert.Empty(t, errorOutput.String())
+}
+
+func TestRelaySpool(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "gelf-relay")
+	assert.Nil(t, err, "Unexpected error")
+	defer os.RemoveAll(dir)
+
+	var (
+		s *spool
+		w = &flakyWriter{down: true}
+	)
+
+	s, err = newSpool(dir, 24)
+	assert.Nil(t, err, "Unexpected error")
+
+	var r = newTestRelay(w, s, 1)
+
+	r.forward([]byte(`{"n":1}`))
+	r.forward([]byte(`{"n":2}`))
+	r.forward([]byte(`{"n":333}`))
+
+	assert.Equal(t, uint64(2), r.spooled.Load())
+	assert.Equal(t, uint64(1), r.dropped.Load())
+
+	var data []byte
+	data, err = ioutil.ReadFile(filepath.Join(dir, spoolFileName))
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "{\"n\":1}\x00{\"n\":2}\x00", string(data))
+
+	// spool of previous run is kept
+	s, err = newSpool(dir, 24)
+	assert.Nil(t, err, "Unexpected error")
+	assert.False(t, s.empty(), "Expected not empty spool")
+
+	r.replay()
+	assert.False(t, r.spool.empty(), "Expected not empty spool")
+
+	w.down = false
+	r.forward([]byte(`{"n":4}`))
+	r.replay()
+	assert.True(t, r.spool.empty(), "Expected empty spool")
+	assert.Equal(t, []string{`{"n":1}`, `{"n":2}`, `{"n":4}`}, w.payloads)
+
+	r.forward([]byte(`{"n":5}`))
+	assert.Equal(t, []string{`{"n":1}`, `{"n":2}`, `{"n":4}`, `{"n":5}`}, w.payloads)
+
+	_, err = os.Stat(filepath.Join(dir, spoolFileName))
+	assert.True(t, os.IsNotExist(err), "Expected removed spool file")
+}
+
+func TestRelayRetry(t *testing.T) {
+	var (
+		w = &flakyWriter{down: true}
+		r = newTestRelay(w, nil, 1)
+	)
+
+	go r.run()
+	assert.Nil(t, r.receive([]byte(`{"n":1}`)), "Unexpected error")
+	assert.Nil(t, r.receive([]byte(`{"n":2}`)), "Unexpected error")
+	assert.Nil(t, r.receive([]byte(`{"n":3}`)), "Unexpected error")
+
+	r.close()
+
+	assert.Empty(t, w.payloads)
+	assert.Equal(t, uint64(3), r.dropped.Load())
+}
+
+// newTestRelay create relay with fast retries.
+func newTestRelay(w io.Writer, s *spool, buffer int) *relay {
+	var decoder, _ = gelfdecode.NewDecoder()
+	return &relay{
+		decoder:     decoder,
+		writer:      w,
+		spool:       s,
+		retry:       time.Millisecond,
+		errorOutput: ioutil.Discard,
+		queue:       make(chan []byte, buffer),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+}
+
+// Write implementation of io.Writer.
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.down {
+		return 0, errDown
+	}
+
+	w.payloads = append(w.payloads, string(p))
+
+	return len(p), nil
+}
+
+func TestSpoolAppendWhileReplay(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "gelf-relay")
+	assert.Nil(t, err, "Unexpected error")
+	defer os.RemoveAll(dir)
+
+	var s *spool
+	s, err = newSpool(dir, 1024)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Nil(t, s.append([]byte(`{"n":1}`)), "Unexpected error")
+	assert.Nil(t, s.append([]byte(`{"n":2}`)), "Unexpected error")
+
+	var (
+		sending  = make(chan struct{})
+		release  = make(chan struct{})
+		replayed = make(chan error)
+		payloads []string
+	)
+
+	go func() {
+		replayed <- s.replay(func(payload []byte) error {
+			if len(payloads) == 1 {
+				close(sending)
+				<-release
+				return errDown
+			}
+
+			payloads = append(payloads, string(payload))
+
+			return nil
+		})
+	}()
+
+	// append does not wait for spooled message being sent
+	<-sending
+	assert.Nil(t, s.append([]byte(`{"n":3}`)), "Unexpected error")
+	close(release)
+	assert.Nil(t, <-replayed, "Unexpected error")
+	assert.Equal(t, []string{`{"n":1}`}, payloads)
+
+	var data []byte
+	data, err = ioutil.ReadFile(filepath.Join(dir, spoolFileName))
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "{\"n\":2}\x00{\"n\":3}\x00", string(data))
+	assert.Equal(t, int64(len(data)), s.size)
+}
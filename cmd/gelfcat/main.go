@@ -0,0 +1,213 @@
+// Command gelfcat sends lines read from stdin to Graylog as GELF messages.
+//
+// Each line is sent as a separate message: lines with JSON objects are sent with object keys as GELF fields,
+// other lines are sent as short_message:
+//
+//	echo "Hello" | gelfcat -addr graylog:12201
+//	echo '{"short_message":"Hello","level":3,"user":"alice"}' | gelfcat -transport tcp -addr graylog:12201
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+)
+
+type (
+	// fields repeatable key=value flag.
+	fields []zapcore.Field
+)
+
+var (
+	// compressionTypes maps compression flag values onto compression types.
+	compressionTypes = map[string]int{
+		"none": gelf.CompressionNone,
+		"gzip": gelf.CompressionGzip,
+		"zlib": gelf.CompressionZlib,
+	}
+
+	// errInvalidField triggered when field flag is not in key=value format.
+	errInvalidField = errors.New("field should be in key=value format")
+)
+
+func main() {
+	var (
+		err      error
+		host, _  = os.Hostname()
+		extra    fields
+		flags    = flag.NewFlagSet("gelfcat", flag.ExitOnError)
+		addr     = flags.String("addr", "127.0.0.1:12201", "GELF input address, URL for http transport")
+		tr       = flags.String("transport", gelf.TransportUDP, "transport: udp, tcp, tls or http")
+		compress = flags.String("compress", "gzip", "compression type: none, gzip or zlib")
+		level    = flags.Int("compress-level", gzip.BestCompression, "compression level")
+		chunk    = flags.Int("chunk", gelf.DefaultChunkSize, "UDP chunk size")
+		timeout  = flags.Duration("timeout", gelf.DefaultTimeout, "dial, write and HTTP request timeout")
+		insecure = flags.Bool("insecure", false, "skip TLS certificate verification")
+	)
+
+	flags.StringVar(&host, "host", host, "GELF host field")
+	flags.Var(&extra, "field", "additional field in key=value format, can be repeated")
+	_ = flags.Parse(os.Args[1:])
+
+	var compressionType, ok = compressionTypes[*compress]
+	if !ok {
+		fail(gelf.ErrUnknownCompressionType)
+	}
+
+	var options = []gelf.Option{
+		gelf.Addr(*addr),
+		gelf.Host(host),
+		gelf.Transport(*tr),
+		gelf.CompressionType(compressionType),
+		gelf.CompressionLevel(*level),
+		gelf.ChunkSize(*chunk),
+		gelf.Timeout(*timeout),
+	}
+
+	if *insecure {
+		options = append(options, gelf.TLSConfig(&tls.Config{InsecureSkipVerify: true}))
+	}
+
+	var core zapcore.Core
+	if core, err = gelf.NewCore(options...); err != nil {
+		fail(err)
+	}
+
+	if err = send(core.With(extra), os.Stdin); err != nil {
+		fail(err)
+	}
+}
+
+// send sends each line of r as GELF message.
+func send(core zapcore.Core, r io.Reader) error {
+	var (
+		failed  int
+		scanner = bufio.NewScanner(r)
+	)
+
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var line = bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry, fields = parseLine(line)
+		if err := core.Write(entry, fields); err != nil {
+			fmt.Fprintf(os.Stderr, "gelfcat: %v\n", err)
+			failed++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if err := core.Sync(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to send %d messages", failed)
+	}
+
+	return nil
+}
+
+// parseLine parse JSON object or plain text line to entry and fields.
+func parseLine(line []byte) (zapcore.Entry, []zapcore.Field) {
+	var (
+		object map[string]interface{}
+		entry  = zapcore.Entry{
+			Level:   zapcore.InfoLevel,
+			Time:    time.Now(),
+			Message: string(line),
+		}
+	)
+
+	if line[0] != '{' || json.Unmarshal(line, &object) != nil {
+		return entry, nil
+	}
+
+	entry.Message = ""
+
+	var fields = make([]zapcore.Field, 0, len(object))
+	for key, value := range object {
+		switch key {
+		case "short_message", "message", "msg":
+			entry.Message = fmt.Sprint(value)
+		case "level":
+			if number, ok := value.(float64); ok {
+				entry.Level = levelFromSyslog(int(number))
+			}
+		case "timestamp":
+			if number, ok := value.(float64); ok {
+				entry.Time = time.Unix(0, int64(number*float64(time.Second)))
+			}
+		case "host", "version":
+			// set by core
+		default:
+			fields = append(fields, zap.Any(key, value))
+		}
+	}
+
+	return entry, fields
+}
+
+// levelFromSyslog maps GELF syslog level onto zap level.
+func levelFromSyslog(level int) zapcore.Level {
+	switch {
+	case level <= 2:
+		return zapcore.DPanicLevel
+	case level == 3:
+		return zapcore.ErrorLevel
+	case level == 4:
+		return zapcore.WarnLevel
+	case level <= 6:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// fail print error and exit.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "gelfcat: %v\n", err)
+	os.Exit(1)
+}
+
+// String implementation of flag.Value.
+func (f *fields) String() string {
+	var keys = make([]string, 0, len(*f))
+	for _, field := range *f {
+		keys = append(keys, field.Key)
+	}
+
+	return strings.Join(keys, ",")
+}
+
+// Set implementation of flag.Value.
+func (f *fields) Set(value string) error {
+	var parts = strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return errInvalidField
+	}
+
+	*f = append(*f, zap.String(parts[0], parts[1]))
+
+	return nil
+}
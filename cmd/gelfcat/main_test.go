@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestSend(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.TCPAddr()),
+		gelf.Transport(gelf.TransportTCP),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var extra fields
+	assert.Nil(t, extra.Set("env=test"), "Unexpected error")
+	assert.Equal(t, errInvalidField, extra.Set("invalid"))
+
+	var input = "plain text\n\n" + `{"short_message":"json","level":3,"timestamp":1500000000.5,"user":"alice"}` + "\n"
+	assert.Nil(t, send(core.With(extra), strings.NewReader(input)), "Unexpected error")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Len(t, messages, 2)
+
+	assert.Equal(t, "plain text", messages[0]["short_message"])
+	assert.Equal(t, float64(6), messages[0]["level"])
+	assert.Equal(t, "test", messages[0]["_env"])
+
+	assert.Equal(t, "json", messages[1]["short_message"])
+	assert.Equal(t, float64(3), messages[1]["level"])
+	assert.Equal(t, 1500000000.5, messages[1]["timestamp"])
+	assert.Equal(t, "alice", messages[1]["_user"])
+	assert.Equal(t, "test", messages[1]["_env"])
+}
+
+func TestLevelFromSyslog(t *testing.T) {
+	var levels = map[int]zapcore.Level{
+		0: zapcore.DPanicLevel,
+		3: zapcore.ErrorLevel,
+		4: zapcore.WarnLevel,
+		5: zapcore.InfoLevel,
+		6: zapcore.InfoLevel,
+		7: zapcore.DebugLevel,
+	}
+
+	for level, expected := range levels {
+		assert.Equal(t, expected, levelFromSyslog(level))
+	}
+}
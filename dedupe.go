@@ -0,0 +1,240 @@
+package gelf
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// RepeatCountKey summary entry field with count of suppressed repeats.
+	RepeatCountKey = "_repeat_count"
+
+	// FirstTimestampKey summary entry field with timestamp of the first entry in window.
+	FirstTimestampKey = "_first_timestamp"
+
+	// LastTimestampKey summary entry field with timestamp of the last suppressed repeat.
+	LastTimestampKey = "_last_timestamp"
+)
+
+type (
+	// dedupeConf duplicate suppression options.
+	dedupeConf struct {
+		window time.Duration
+		keys   *fieldPatterns
+	}
+
+	// deduplicator suppresses repeated entries and reports them when window closes.
+	deduplicator struct {
+		dedupeConf
+		mu      sync.Mutex
+		windows map[uint64]*dedupeWindow
+		closed  bool
+
+		// pending counts open windows, so close waits for summaries written by fired timers.
+		pending sync.WaitGroup
+	}
+
+	// dedupeWindow repeats of one entry fingerprint.
+	dedupeWindow struct {
+		core   *wrappedCore
+		entry  zapcore.Entry
+		fields []zapcore.Field
+		first  time.Time
+		last   time.Time
+		count  uint64
+		timer  *time.Timer
+	}
+)
+
+var (
+	// ErrInvalidDedupeWindow triggered when passed invalid duplicate suppression window.
+	ErrInvalidDedupeWindow = errors.New("invalid dedupe window")
+)
+
+// Deduplicate set suppression of repeated entries.
+//
+// Entries are fingerprinted by level, message, caller and values of fields which keys match exact names or glob
+// patterns of keys. The first entry is logged, repeats logged within window after it are suppressed, and when window
+// closes the first entry is logged again with _repeat_count, _first_timestamp and _last_timestamp fields.
+// Pending summaries are logged on Sync and Close.
+func Deduplicate(window time.Duration, keys ...string) Option {
+	return optionFunc(func(conf *optionConf) (err error) {
+		if window <= 0 {
+			return ErrInvalidDedupeWindow
+		}
+
+		if conf.dedupe == nil {
+			conf.dedupe = &dedupeConf{}
+		}
+
+		conf.dedupe.window = window
+		conf.dedupe.keys, err = appendFieldPatterns(conf.dedupe.keys, keys)
+
+		return err
+	})
+}
+
+// newDeduplicator create deduplicator, returns nil when duplicate suppression is not set.
+func newDeduplicator(conf *optionConf) *deduplicator {
+	if conf.dedupe == nil {
+		return nil
+	}
+
+	return &deduplicator{
+		dedupeConf: *conf.dedupe,
+		windows:    make(map[uint64]*dedupeWindow),
+	}
+}
+
+// selected returns fields which values are part of fingerprint.
+func (d *deduplicator) selected(dst, fields []zapcore.Field) []zapcore.Field {
+	for _, field := range fields {
+		if d.keys != nil && d.keys.match(strings.TrimLeft(field.Key, "_")) {
+			dst = append(dst, field)
+		}
+	}
+
+	return dst
+}
+
+// allow returns true when entry is not a repeat of entry logged within window.
+func (d *deduplicator) allow(core *wrappedCore, e zapcore.Entry, fields []zapcore.Field) bool {
+	var key = d.fingerprint(e, d.selected(core.dedupeFields[:len(core.dedupeFields):len(core.dedupeFields)], fields))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return true
+	}
+
+	if dw, ok := d.windows[key]; ok {
+		dw.count++
+		dw.last = e.Time
+
+		return false
+	}
+
+	var dw = &dedupeWindow{
+		core:   core,
+		entry:  e,
+		fields: append([]zapcore.Field(nil), fields...),
+		first:  e.Time,
+		last:   e.Time,
+	}
+
+	dw.timer = time.AfterFunc(d.window, func() {
+		_ = d.closeWindow(key, dw)
+	})
+
+	d.windows[key] = dw
+	d.pending.Add(1)
+
+	return true
+}
+
+// closeWindow removes window and writes summary entry when repeats were suppressed.
+func (d *deduplicator) closeWindow(key uint64, dw *dedupeWindow) error {
+	d.mu.Lock()
+	if d.windows[key] != dw {
+		d.mu.Unlock()
+		return nil
+	}
+
+	delete(d.windows, key)
+	var count, last = dw.count, dw.last
+	d.mu.Unlock()
+
+	defer d.pending.Done()
+
+	if count == 0 {
+		return nil
+	}
+
+	return dw.core.write(dw.entry, append(dw.fields,
+		zap.Uint64(RepeatCountKey, count),
+		zap.Float64(FirstTimestampKey, epochSeconds(dw.first)),
+		zap.Float64(LastTimestampKey, epochSeconds(last)),
+	))
+}
+
+// flush stops timers and closes all windows.
+func (d *deduplicator) flush() (err error) {
+	d.mu.Lock()
+	var windows = make(map[uint64]*dedupeWindow, len(d.windows))
+	for key, dw := range d.windows {
+		windows[key] = dw
+	}
+	d.mu.Unlock()
+
+	for key, dw := range windows {
+		dw.timer.Stop()
+		if cErr := d.closeWindow(key, dw); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+
+	return err
+}
+
+// close stops opening of windows, closes all windows and waits for summaries written by fired timers.
+func (d *deduplicator) close() error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	var err = d.flush()
+	d.pending.Wait()
+
+	return err
+}
+
+// fingerprint returns FNV-64a hash of entry level, message, caller and selected fields.
+func (d *deduplicator) fingerprint(e zapcore.Entry, fields []zapcore.Field) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	var hash = uint64(offset64)
+	var write = func(value string) {
+		for i := 0; i < len(value); i++ {
+			hash ^= uint64(value[i])
+			hash *= prime64
+		}
+
+		// separator, so that adjacent values do not collide
+		hash ^= 0xff
+		hash *= prime64
+	}
+
+	write(e.Level.String())
+	write(e.Message)
+	write(e.Caller.File)
+	write(strconv.Itoa(e.Caller.Line))
+	write(e.Caller.Function)
+
+	for _, field := range fields {
+		write(strings.TrimLeft(field.Key, "_"))
+		write(strconv.Itoa(int(field.Type)))
+		write(strconv.FormatInt(field.Integer, 10))
+		write(field.String)
+		if field.Interface != nil {
+			write(fmt.Sprint(field.Interface))
+		}
+	}
+
+	return hash
+}
+
+// epochSeconds returns t as floating-point seconds since epoch, like GELF timestamp.
+func epochSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
@@ -0,0 +1,114 @@
+package gelf_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestDeduplicate(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.Deduplicate(100*time.Millisecond, "user"),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	for i := 0; i < 5; i++ {
+		logger.Error("retry failed", zap.String("user", "alice"), zap.Int("attempt", i))
+	}
+
+	logger.With(zap.String("user", "bob")).Error("retry failed")
+	logger.Warn("retry failed", zap.String("user", "alice"))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(3, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, 0.0, messages[0]["_attempt"])
+	assert.Equal(t, "bob", messages[1]["_user"])
+	assert.Equal(t, 4.0, messages[2]["level"])
+	assert.NotContains(t, messages[0], gelf.RepeatCountKey)
+
+	messages, err = server.Wait(4, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var summary = messages[3]
+	assert.Equal(t, "retry failed", summary["short_message"])
+	assert.Equal(t, "alice", summary["_user"])
+	assert.Equal(t, 0.0, summary["_attempt"])
+	assert.Equal(t, 4.0, summary[gelf.RepeatCountKey])
+	assert.Equal(t, messages[0]["timestamp"], summary[gelf.FirstTimestampKey])
+	assert.GreaterOrEqual(t, summary[gelf.LastTimestampKey], summary[gelf.FirstTimestampKey])
+
+	// window closed, the next entry is logged
+	logger.Error("retry failed", zap.String("user", "alice"))
+	messages, err = server.Wait(5, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.NotContains(t, messages[4], gelf.RepeatCountKey)
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Len(t, server.Messages(), 5)
+}
+
+func TestDeduplicateSync(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.Deduplicate(time.Hour),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	for i := 0; i < 3; i++ {
+		logger.Info("connection refused")
+	}
+
+	assert.Nil(t, core.Sync(), "Unexpected error")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, 2.0, messages[1][gelf.RepeatCountKey])
+
+	core, err = gelf.NewCore(gelf.Deduplicate(0))
+	assert.Equal(t, gelf.ErrInvalidDedupeWindow, err)
+	assert.Nil(t, core, "Expected nil")
+}
+
+func TestDeduplicateClose(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.Deduplicate(50*time.Millisecond),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	for i := 0; i < 3; i++ {
+		logger.Error("retry failed")
+	}
+
+	assert.Nil(t, core.(io.Closer).Close(), "Unexpected error")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, 2.0, messages[1][gelf.RepeatCountKey])
+
+	// window timer is stopped on close, so summary is not written again
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, server.Messages(), 2)
+}
@@ -0,0 +1,93 @@
+package gelf
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type (
+	// FieldsError is implemented by errors contributing own additional fields when errors are expanded, keys of
+	// fields are prefixed by error field key, e.g. status field of error field becomes _error_status.
+	FieldsError interface {
+		error
+
+		// Fields returns additional fields of error.
+		Fields() []zapcore.Field
+	}
+
+	// wrapper is implemented by errors wrapping other error, see errors.Unwrap of Go 1.13.
+	wrapper interface {
+		Unwrap() error
+	}
+)
+
+// ExpandErrors set expanding of error fields into structured fields.
+//
+// Error field with key error is sent as _error message, _error_type concrete type and _error_chain with type and
+// message of each error of Unwrap chain when error wraps other errors. Verbose error, e.g. pkg/errors stack,
+// is sent as full_message when entry has no stack trace, otherwise as _errorVerbose. Errors implementing FieldsError
+// contribute own fields.
+func ExpandErrors(value bool) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.expandErrors = value
+		return nil
+	})
+}
+
+// appendError appends expanded error fields, full is true when verbose error may be sent as full_message and is reset
+// once it is sent.
+func (w *wrappedCore) appendError(escaped []zapcore.Field, key string, err error, full *bool) []zapcore.Field {
+	var message = err.Error()
+	escaped = w.appendRedacted(escaped, zap.String(key, message))
+	escaped = w.appendRedacted(escaped, zap.String(key+"_type", fmt.Sprintf("%T", err)))
+
+	if unwrap(err) != nil {
+		var chain strings.Builder
+		for e := err; e != nil; e = unwrap(e) {
+			if chain.Len() > 0 {
+				chain.WriteByte('\n')
+			}
+
+			fmt.Fprintf(&chain, "%T: %s", e, e.Error())
+		}
+
+		escaped = w.appendRedacted(escaped, zap.String(key+"_chain", chain.String()))
+	}
+
+	for e := err; e != nil; e = unwrap(e) {
+		if fe, ok := e.(FieldsError); ok {
+			for _, field := range fe.Fields() {
+				field.Key = key + "_" + strings.TrimLeft(field.Key, "_")
+				escaped = w.appendRedacted(escaped, field)
+			}
+		}
+	}
+
+	if _, ok := err.(fmt.Formatter); !ok {
+		return escaped
+	}
+
+	var verbose = fmt.Sprintf("%+v", err)
+	if verbose == message {
+		return escaped
+	}
+
+	if *full {
+		*full = false
+		return w.appendRedacted(escaped, zap.String("full_message", verbose))
+	}
+
+	return w.appendRedacted(escaped, zap.String(key+"Verbose", verbose))
+}
+
+// unwrap returns error wrapped by err or nil.
+func unwrap(err error) error {
+	if w, ok := err.(wrapper); ok {
+		return w.Unwrap()
+	}
+
+	return nil
+}
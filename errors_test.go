@@ -0,0 +1,105 @@
+package gelf_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+type (
+	// statusError error contributing own fields.
+	statusError struct {
+		status int
+	}
+
+	// wrapError error wrapping other error.
+	wrapError struct {
+		message string
+		err     error
+	}
+
+	// verboseError error with verbose format, like pkg/errors errors.
+	verboseError struct {
+		message string
+	}
+)
+
+func TestExpandErrors(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.ExpandErrors(true),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	logger.Error(
+		"request failed",
+		zap.Error(&wrapError{message: "fetch user", err: &statusError{status: 404}}),
+		zap.NamedError("cause", &verboseError{message: "broken"}),
+	)
+	logger.Error("plain", zap.Error(io.EOF))
+	logger.WithOptions(zap.AddStacktrace(zap.ErrorLevel)).Error("with stack", zap.Error(&verboseError{message: "broken"}))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(3, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Equal(t, "fetch user: status 404", message["_error"])
+	assert.Equal(t, "*gelf_test.wrapError", message["_error_type"])
+	assert.Equal(t, "*gelf_test.wrapError: fetch user: status 404\n*gelf_test.statusError: status 404", message["_error_chain"])
+	assert.Equal(t, 404.0, message["_error_status"])
+	assert.Equal(t, "broken", message["_cause"])
+	assert.Equal(t, "broken\nverbose stack", message["full_message"])
+	assert.NotContains(t, message, "_errorVerbose")
+
+	message = messages[1]
+	assert.Equal(t, "EOF", message["_error"])
+	assert.Equal(t, "*errors.errorString", message["_error_type"])
+	assert.NotContains(t, message, "_error_chain")
+	assert.NotContains(t, message, "full_message")
+
+	message = messages[2]
+	assert.Equal(t, "broken\nverbose stack", message["_errorVerbose"])
+	assert.Contains(t, message["full_message"], "TestExpandErrors")
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("status %d", e.status)
+}
+
+func (e *statusError) Fields() []zapcore.Field {
+	return []zapcore.Field{zap.Int("status", e.status)}
+}
+
+func (e *wrapError) Error() string {
+	return e.message + ": " + e.err.Error()
+}
+
+func (e *wrapError) Unwrap() error {
+	return e.err
+}
+
+func (e *verboseError) Error() string {
+	return e.message
+}
+
+func (e *verboseError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = io.WriteString(s, e.message+"\nverbose stack")
+		return
+	}
+
+	_, _ = io.WriteString(s, e.message)
+}
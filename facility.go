@@ -0,0 +1,84 @@
+package gelf
+
+import (
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// syslogFacilities syslog facility names indexed by code.
+	// See https://tools.ietf.org/html/rfc5424#section-6.2.1.
+	syslogFacilities = []string{
+		"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+		"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+		"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+	}
+)
+
+// Facility set facility of entries, sent as _facility field or as facility field in GELF 1.0 mode. When Facility or
+// LoggerFacility is set, fields with facility key passed to With and Write are not sent.
+func Facility(value string) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.facility = value
+		return nil
+	})
+}
+
+// LoggerFacility set facility of entries of logger with name or its children, e.g. audit matches audit and audit.db,
+// facility of the longest matched name is used.
+func LoggerFacility(name, facility string) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if conf.loggerFacilities == nil {
+			conf.loggerFacilities = make(map[string]string)
+		}
+
+		conf.loggerFacilities[name] = facility
+
+		return nil
+	})
+}
+
+// SyslogFacility returns name of syslog facility code, e.g. local0 for 16, or code itself when it is unknown.
+func SyslogFacility(code int) string {
+	if code >= 0 && code < len(syslogFacilities) {
+		return syslogFacilities[code]
+	}
+
+	return strconv.Itoa(code)
+}
+
+// appendFacility appends facility field of logger.
+func (w *wrappedCore) appendFacility(escaped []zapcore.Field, loggerName string) []zapcore.Field {
+	var facility = w.conf.facilityOf(loggerName)
+	if facility == "" {
+		return escaped
+	}
+
+	return append(escaped, zap.String(escapeProtocolKey(w.conf.protocol, "facility"), facility))
+}
+
+// facilitySet returns true when Facility or LoggerFacility is set.
+func (conf *optionConf) facilitySet() bool {
+	return conf.facility != "" || len(conf.loggerFacilities) > 0
+}
+
+// facilityOf returns facility of logger.
+func (conf *optionConf) facilityOf(loggerName string) string {
+	for name := loggerName; len(conf.loggerFacilities) > 0; {
+		if facility, ok := conf.loggerFacilities[name]; ok {
+			return facility
+		}
+
+		var i = strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+
+		name = name[:i]
+	}
+
+	return conf.facility
+}
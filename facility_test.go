@@ -0,0 +1,54 @@
+package gelf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestFacility(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.Facility("app"),
+		gelf.LoggerFacility("audit", gelf.SyslogFacility(13)),
+		gelf.LoggerFacility("audit.db", "db"),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	logger.Info("default")
+	logger.Named("audit").Named("http").Info("audit")
+	logger.Named("audit").Named("db").Info("db")
+
+	core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.ProtocolVersion(gelf.ProtocolVersion10),
+		gelf.LoggerFacility("worker", gelf.SyslogFacility(16)),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	logger = zap.New(core)
+	logger.Named("worker").Info("legacy")
+	logger.Info("no facility")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(5, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "app", messages[0]["_facility"])
+	assert.Equal(t, "security", messages[1]["_facility"])
+	assert.Equal(t, "db", messages[2]["_facility"])
+	assert.Equal(t, "local0", messages[3]["facility"])
+	assert.NotContains(t, messages[3], "_facility")
+	assert.NotContains(t, messages[4], "facility")
+
+	assert.Equal(t, "kern", gelf.SyslogFacility(0))
+	assert.Equal(t, "42", gelf.SyslogFacility(42))
+}
@@ -0,0 +1,107 @@
+package gelf
+
+import (
+	"errors"
+	"path"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type (
+	// fieldPatterns exact names and glob patterns of field keys.
+	fieldPatterns struct {
+		names map[string]struct{}
+		globs []string
+	}
+
+	// fieldFilter filters additional fields by allowlist and denylist.
+	fieldFilter struct {
+		allow *fieldPatterns
+		deny  *fieldPatterns
+	}
+)
+
+var (
+	// ErrInvalidFieldPattern triggered when passed invalid field pattern.
+	ErrInvalidFieldPattern = errors.New("invalid field pattern")
+)
+
+// AllowFields set allowlist of additional fields, fields which keys match neither exact name nor glob pattern are not
+// sent. Patterns are matched against keys without underscore prefix and applied to fields passed to With and Write
+// and to fields expanded from Context, e.g. trace_*.
+func AllowFields(patterns ...string) Option {
+	return optionFunc(func(conf *optionConf) (err error) {
+		conf.allowFields, err = appendFieldPatterns(conf.allowFields, patterns)
+		return err
+	})
+}
+
+// DenyFields set denylist of additional fields, fields which keys match exact name or glob pattern are not sent.
+// Denylist is applied after allowlist.
+func DenyFields(patterns ...string) Option {
+	return optionFunc(func(conf *optionConf) (err error) {
+		conf.denyFields, err = appendFieldPatterns(conf.denyFields, patterns)
+		return err
+	})
+}
+
+// appendFieldPatterns validates and appends patterns.
+func appendFieldPatterns(fp *fieldPatterns, patterns []string) (*fieldPatterns, error) {
+	if fp == nil {
+		fp = &fieldPatterns{names: make(map[string]struct{})}
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimLeft(pattern, "_")
+		if !strings.ContainsAny(pattern, `*?[\`) {
+			fp.names[pattern] = struct{}{}
+			continue
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, ErrInvalidFieldPattern
+		}
+
+		fp.globs = append(fp.globs, pattern)
+	}
+
+	return fp, nil
+}
+
+// newFieldFilter create field filter, returns nil when neither allowlist nor denylist set.
+func newFieldFilter(conf *optionConf) *fieldFilter {
+	if conf.allowFields == nil && conf.denyFields == nil {
+		return nil
+	}
+
+	return &fieldFilter{
+		allow: conf.allowFields,
+		deny:  conf.denyFields,
+	}
+}
+
+// allowed returns true when field should be sent.
+func (f *fieldFilter) allowed(field zapcore.Field) bool {
+	var key = strings.TrimLeft(field.Key, "_")
+	if f.allow != nil && !f.allow.match(key) {
+		return false
+	}
+
+	return f.deny == nil || !f.deny.match(key)
+}
+
+// match returns true when key matches exact name or glob pattern.
+func (fp *fieldPatterns) match(key string) bool {
+	if _, ok := fp.names[key]; ok {
+		return true
+	}
+
+	for _, glob := range fp.globs {
+		if matched, _ := path.Match(glob, key); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+package gelf_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestAllowFields(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.AllowFields("user", "_request_*"),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	zap.New(core).
+		With(zap.String("user", "alice"), zap.String("noisy", "value")).
+		Info("allowed", zap.String("request_id", "1"), zap.String("_request_path", "/"), zap.Int("other", 1))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Equal(t, "alice", message["_user"])
+	assert.Equal(t, "1", message["_request_id"])
+	assert.Equal(t, "/", message["_request_path"])
+	assert.NotContains(t, message, "_noisy")
+	assert.NotContains(t, message, "_other")
+	assert.Equal(t, "allowed", message["short_message"])
+	assert.Equal(t, "localhost", message["host"])
+}
+
+func TestDenyFields(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.AllowFields("user*"),
+		gelf.DenyFields("user_agent", "debug.*"),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	zap.New(core).
+		With(zap.String("debug.sql", "select")).
+		Info("denied", zap.String("user", "alice"), zap.String("user_agent", "curl"))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Equal(t, "alice", message["_user"])
+	assert.NotContains(t, message, "_user_agent")
+	assert.NotContains(t, message, "_debug.sql")
+
+	core, err = gelf.NewCore(gelf.DenyFields("[debug"))
+	assert.Equal(t, gelf.ErrInvalidFieldPattern, err)
+	assert.Nil(t, core, "Expected nil")
+}
+
+func TestFieldsAllocations(t *testing.T) {
+	// nobody reads datagrams, so only allocations of core are counted
+	var conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err, "Unexpected error")
+	defer conn.Close()
+
+	var (
+		entry  = zapcore.Entry{Message: "allocations"}
+		fields = []zapcore.Field{zap.String("user", "alice"), zap.Int("count", 1)}
+	)
+
+	var allocs = func(options ...gelf.Option) float64 {
+		var core, err = gelf.NewCore(append(
+			options,
+			gelf.Addr(conn.LocalAddr().String()),
+			gelf.CompressionType(gelf.CompressionNone),
+		)...)
+		assert.Nil(t, err, "Unexpected error")
+
+		return testing.AllocsPerRun(100, func() {
+			_ = core.Write(entry, fields)
+		})
+	}
+
+	assert.Equal(t, allocs(), allocs(gelf.AllowFields("user", "c*"), gelf.DenyFields("password", "*_token")))
+}
+
+func TestFieldsContext(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.DenyFields("_trace_flags"),
+		gelf.RedactKeys(gelf.RedactMask, "span_id"),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var ctx = gelf.WithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	zap.New(core).Info("traced", gelf.Context(ctx), zap.String("user", "alice"))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", message[gelf.TraceIDKey])
+	assert.Equal(t, gelf.RedactedValue, message[gelf.SpanIDKey])
+	assert.NotContains(t, message, gelf.TraceFlagsKey)
+	assert.Equal(t, "alice", message["_user"])
+}
@@ -4,12 +4,18 @@ import (
 	"bytes"
 	"compress/gzip"
 	"compress/zlib"
-	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -56,6 +62,32 @@ type (
 		writeSyncers     []zapcore.WriteSyncer
 		compressionType  int
 		compressionLevel int
+		onError          func(err error, entry zapcore.Entry)
+		observers        []Observer
+		transport        string
+		tlsConfig        *tls.Config
+		timeout          time.Duration
+		sampling         map[zapcore.Level]samplingConf
+		rateLimit        int
+		rateBurst        int
+		summaryInterval  time.Duration
+		addSource        bool
+		traceExtractors  []TraceExtractor
+		redactKeys       []redactKeyRule
+		redactValues     []redactValueRule
+		redactSalt       string
+		allowFields      *fieldPatterns
+		denyFields       *fieldPatterns
+		dedupe           *dedupeConf
+		expandErrors     bool
+		stack            stackConf
+		splitCaller      bool
+		callerFileLine   bool
+		protocol         string
+		facility         string
+		loggerFacilities map[string]string
+		messageIDs       MessageIDGenerator
+		compressWorkers  int
 	}
 
 	// optionFunc wraps a func so it satisfies the Option interface.
@@ -64,10 +96,20 @@ type (
 	// implement io.Writer
 	writer struct {
 		conn             net.Conn
+		sender           sender
 		chunkSize        int
 		chunkDataSize    int
 		compressionType  int
 		compressionLevel int
+		transport        string
+		stats            *stats
+		limiter          *rateLimiter
+		observers        []Observer
+		messageIDs       MessageIDGenerator
+		pipeline         *pipeline
+
+		// mu serializes sending, so chunks of one message are sent contiguously.
+		mu sync.Mutex
 	}
 
 	// implement io.WriteCloser.
@@ -77,7 +119,16 @@ type (
 
 	// implement zapcore.Core.
 	wrappedCore struct {
-		core zapcore.Core
+		core     zapcore.Core
+		conf     *optionConf
+		writer   *writer
+		sampler  *sampler
+		redactor *redactor
+		filter   *fieldFilter
+		dedupe   *deduplicator
+
+		// dedupeFields fields passed to With which values are part of dedupe fingerprint.
+		dedupeFields []zapcore.Field
 	}
 )
 
@@ -97,70 +148,57 @@ var (
 
 	// Ensure *writer implements zapcore.WriteSyncer.
 	_ zapcore.WriteSyncer = (*writer)(nil)
+
+	// Ensure *wrappedCore implements io.Closer.
+	_ io.Closer = (*wrappedCore)(nil)
 )
 
 // NewCore zap core constructor.
-func NewCore(options ...Option) (_ zapcore.Core, err error) {
-	var conf = optionConf{
-		addr: "127.0.0.1:12201",
-		host: "localhost",
-		encoder: zapcore.EncoderConfig{
-			TimeKey:        "timestamp",
-			NameKey:        "_logger",
-			LevelKey:       "level",
-			CallerKey:      "_caller",
-			MessageKey:     "short_message",
-			StacktraceKey:  "full_message",
-			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeName:     zapcore.FullNameEncoder,
-			EncodeTime:     zapcore.EpochTimeEncoder,
-			EncodeLevel:    levelEncoder,
-			EncodeCaller:   zapcore.ShortCallerEncoder,
-			EncodeDuration: zapcore.SecondsDurationEncoder,
-		},
-		version:          "1.1",
-		enabler:          zap.NewAtomicLevel(),
-		chunkSize:        DefaultChunkSize,
-		writeSyncers:     make([]zapcore.WriteSyncer, 0, 8),
-		compressionType:  CompressionGzip,
-		compressionLevel: gzip.BestCompression,
+func NewCore(options ...Option) (zapcore.Core, error) {
+	var core, err = newCore(options)
+	if err != nil {
+		return nil, err
 	}
 
+	return core, nil
+}
+
+// NewWriter GELF writer constructor, each write sends buf as single encoded GELF message.
+//
+// Writer uses transport, compression, chunking, rate limit and observers options, other options are ignored. It may be
+// used to forward already encoded GELF messages.
+func NewWriter(options ...Option) (_ io.WriteCloser, err error) {
+	var conf = newOptionConf()
 	for _, option := range options {
 		if err = option.apply(&conf); err != nil {
 			return nil, err
 		}
 	}
 
-	var w = &writer{
-		chunkSize:        conf.chunkSize,
-		chunkDataSize:    conf.chunkSize - 12, // chunk size - chunk header size
-		compressionType:  conf.compressionType,
-		compressionLevel: conf.compressionLevel,
-	}
-
-	if w.conn, err = net.Dial("udp", conf.addr); err != nil {
+	var w *writer
+	if w, err = newWriter(&conf); err != nil {
 		return nil, err
 	}
 
-	var ws zapcore.WriteSyncer = w
-	if len(conf.writeSyncers) > 0 {
-		var writers = append([]zapcore.WriteSyncer{w}, conf.writeSyncers...)
-		ws = zapcore.NewMultiWriteSyncer(writers...)
+	return w, nil
+}
+
+// NewEncoderConfig create zapcore.EncoderConfig with GELF keys and encoders.
+func NewEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:        "timestamp",
+		NameKey:        "_logger",
+		LevelKey:       "level",
+		CallerKey:      "_caller",
+		MessageKey:     "short_message",
+		StacktraceKey:  fullMessageKey,
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeName:     zapcore.FullNameEncoder,
+		EncodeTime:     zapcore.EpochTimeEncoder,
+		EncodeLevel:    levelEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
 	}
-
-	var core = zapcore.NewCore(
-		zapcore.NewJSONEncoder(conf.encoder),
-		ws,
-		conf.enabler,
-	)
-
-	return &wrappedCore{
-		core: core.With([]zapcore.Field{
-			zap.String("host", conf.host),
-			zap.String("version", conf.version),
-		}),
-	}, nil
 }
 
 // Addr set GELF address.
@@ -179,7 +217,7 @@ func Host(value string) Option {
 	})
 }
 
-// Version set GELF version.
+// Version set GELF version field, it only changes emitted version, see ProtocolVersion.
 func Version(value string) Option {
 	return optionFunc(func(conf *optionConf) error {
 		conf.version = value
@@ -363,8 +401,42 @@ func CompressionLevel(value int) Option {
 	})
 }
 
-// Write implements io.Writer.
+// OnError set handler of entry delivery errors.
+//
+// When handler is set, errors are passed to it instead of being returned to zap, which reports them to ErrorOutput.
+// Handler is called synchronously on the logging goroutine.
+//
+// With CompressWorkers delivery error of a queued message is passed with entry of the next written message, not the
+// failed one, and delivery error returned by Sync is not passed to handler at all.
+func OnError(value func(err error, entry zapcore.Entry)) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.onError = value
+		return nil
+	})
+}
+
+// Write implements io.Writer, it is safe for concurrent use.
 func (w *writer) Write(buf []byte) (n int, err error) {
+	if w.pipeline != nil {
+		return w.pipeline.write(buf)
+	}
+
+	var cBytes []byte
+	if cBytes, err = w.compress(buf); err != nil {
+		w.observeMessage(MessageEvent{
+			Transport: w.transport,
+			Size:      len(buf),
+			Err:       err,
+		})
+
+		return 0, err
+	}
+
+	return w.send(len(buf), cBytes)
+}
+
+// compress returns compressed buf.
+func (w *writer) compress(buf []byte) (_ []byte, err error) {
 	var (
 		cw   io.WriteCloser
 		cBuf bytes.Buffer
@@ -380,36 +452,111 @@ func (w *writer) Write(buf []byte) (n int, err error) {
 	}
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if n, err = cw.Write(buf); err != nil {
-		return n, err
+	if _, err = cw.Write(buf); err != nil {
+		return nil, err
 	}
 
-	cw.Close()
+	if err = cw.Close(); err != nil {
+		return nil, err
+	}
+
+	return cBuf.Bytes(), nil
+}
+
+// send sends compressed message of size bytes and returns size on success, chunks of one message are never
+// interleaved with other messages.
+func (w *writer) send(size int, cBytes []byte) (n int, err error) {
+	var (
+		start time.Time
+		event = MessageEvent{
+			Transport:      w.transport,
+			Size:           size,
+			CompressedSize: len(cBytes),
+			Chunks:         1,
+		}
+	)
+
+	defer func() {
+		if !start.IsZero() {
+			event.Latency = time.Since(start)
+		}
 
-	var cBytes = cBuf.Bytes()
-	if count := w.chunkCount(cBytes); count > 1 {
-		return w.writeChunked(count, cBytes)
+		event.Err = err
+		w.observeMessage(event)
+	}()
+
+	if w.sender == nil {
+		event.Chunks = w.chunkCount(cBytes)
+	}
+
+	event.Dropped = event.Chunks > MaxChunkCount
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	start = time.Now()
+
+	if w.limiter != nil && !w.limiter.allow(len(cBytes), start) {
+		event.Dropped = true
+		return size, nil
+	}
+
+	if w.sender != nil {
+		if event.Reconnects, err = w.sender.send(cBytes); err != nil {
+			return 0, err
+		}
+
+		return size, nil
+	}
+
+	if event.Chunks > 1 {
+		if _, err = w.writeChunked(event.Chunks, cBytes); err != nil {
+			return 0, err
+		}
+
+		return size, nil
 	}
 
 	if n, err = w.conn.Write(cBytes); err != nil {
-		return n, err
+		return 0, err
 	}
 
 	if n != len(cBytes) {
-		return n, fmt.Errorf("writed %d bytes but should %d bytes", n, len(cBytes))
+		return 0, fmt.Errorf("writed %d bytes but should %d bytes", n, len(cBytes))
 	}
 
-	return n, nil
+	return size, nil
 }
 
-// Sync is a no-op, but required to implement the zapcore.WriteSyncer interface.
+// Sync waits until messages written to compression pipeline are sent and returns their delivery error, otherwise it
+// is a no-op.
 func (w *writer) Sync() error {
+	if w.pipeline != nil {
+		return w.pipeline.sync()
+	}
+
 	return nil
 }
 
+// Close implementation of io.Closer, messages written to compression pipeline are sent before close.
+func (w *writer) Close() (err error) {
+	if w.pipeline != nil {
+		err = w.pipeline.close()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.sender != nil {
+		return multierr.Append(err, w.sender.Close())
+	}
+
+	return multierr.Append(err, w.conn.Close())
+}
+
 // Close implementation of io.WriteCloser.
 func (*writeCloser) Close() error {
 	return nil
@@ -422,66 +569,225 @@ func (w *wrappedCore) Enabled(l zapcore.Level) bool {
 
 // With implementation of zapcore.Core.
 func (w *wrappedCore) With(fields []zapcore.Field) zapcore.Core {
-	return &wrappedCore{core: w.core.With(w.escape(fields))}
+	var clone = &wrappedCore{
+		core:     w.core.With(w.escape(fields, false)),
+		conf:     w.conf,
+		writer:   w.writer,
+		sampler:  w.sampler,
+		redactor: w.redactor,
+		filter:   w.filter,
+		dedupe:   w.dedupe,
+	}
+
+	if w.dedupe != nil {
+		clone.dedupeFields = w.dedupe.selected(w.dedupeFields[:len(w.dedupeFields):len(w.dedupeFields)], fields)
+	}
+
+	return clone
 }
 
 // Check implementation of zapcore.Core.
 func (w *wrappedCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
-	if w.Enabled(e.Level) {
-		return ce.AddCore(e, w)
+	if !w.allow(e) {
+		return ce
 	}
 
-	return ce
+	return ce.AddCore(e, w)
+}
+
+// allow returns true when entry level is enabled and entry is not suppressed by sampling.
+func (w *wrappedCore) allow(e zapcore.Entry) bool {
+	if !w.Enabled(e.Level) {
+		return false
+	}
+
+	return w.sampler == nil || w.sampler.allow(e)
 }
 
 // Write implementation of zapcore.Core.
 func (w *wrappedCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
-	return w.core.Write(e, w.escape(fields))
+	if w.dedupe != nil && !w.dedupe.allow(w, e, fields) {
+		return nil
+	}
+
+	return w.write(e, fields)
+}
+
+// write redacts, escapes and writes entry.
+func (w *wrappedCore) write(e zapcore.Entry, fields []zapcore.Field) error {
+	if w.redactor != nil {
+		e.Message = w.redactor.message(e.Message)
+		e.Stack = w.redactor.message(e.Stack)
+	}
+
+	e.Stack = w.conf.stack.format(e.Stack)
+
+	var (
+		stackKey = w.conf.encoder.StacktraceKey
+		full     = e.Stack == "" || stackKey != fullMessageKey
+		message  string
+	)
+
+	if tmpl := w.conf.fullMessageTemplate(); tmpl != nil {
+		full, message = false, w.fullMessage(tmpl, e, fields)
+		if stackKey == fullMessageKey {
+			e.Stack, message = message, ""
+		}
+	}
+
+	var escaped = w.appendFacility(w.appendCaller(w.escape(fields, full), e.Caller), e.LoggerName)
+	if message != "" {
+		escaped = append(escaped, zap.String(fullMessageKey, message))
+	}
+
+	var err = w.core.Write(e, escaped)
+	w.writer.observeEntry(EntryEvent{
+		Level:     e.Level,
+		Transport: w.writer.transport,
+		Err:       err,
+	})
+
+	if err != nil && w.conf.onError != nil {
+		w.conf.onError(err, e)
+		return nil
+	}
+
+	return err
+}
+
+// fullMessage returns full message rendered by template.
+func (w *wrappedCore) fullMessage(tmpl *template.Template, e zapcore.Entry, fields []zapcore.Field) string {
+	var fm = FullMessage{
+		Message: e.Message,
+		Stack:   e.Stack,
+	}
+
+	for _, field := range fields {
+		if err, ok := field.Interface.(error); ok && field.Type == zapcore.ErrorType {
+			if w.filter == nil || w.filter.allowed(field) {
+				fm.Error = err.Error()
+			}
+
+			break
+		}
+	}
+
+	if w.redactor != nil {
+		fm.Error = w.redactor.message(fm.Error)
+	}
+
+	// template is validated by FullMessageTemplate, so execution errors are ignored
+	var b strings.Builder
+	_ = tmpl.Execute(&b, fm)
+
+	return b.String()
+}
+
+// Stats returns delivery statistics of the core and all cores derived from it by With.
+func (w *wrappedCore) Stats() Stats {
+	return w.writer.stats.snapshot()
 }
 
 // Sync implementation of zapcore.Core.
 func (w *wrappedCore) Sync() error {
+	if w.dedupe != nil {
+		if err := w.dedupe.flush(); err != nil {
+			return err
+		}
+	}
+
+	if w.sampler != nil {
+		if err := w.sampler.flush(); err != nil {
+			return err
+		}
+	}
+
 	return w.core.Sync()
 }
 
+// Close implementation of io.Closer, writes pending summaries, stops summary timers and closes writer. Neither the core
+// nor cores derived from it by With may be used after close.
+func (w *wrappedCore) Close() (err error) {
+	if w.dedupe != nil {
+		err = multierr.Append(err, w.dedupe.close())
+	}
+
+	if w.sampler != nil {
+		err = multierr.Append(err, w.sampler.close())
+	}
+
+	err = multierr.Append(err, w.core.Sync())
+
+	return multierr.Append(err, w.writer.Close())
+}
+
 // apply implements Option.
 func (f optionFunc) apply(conf *optionConf) error {
 	return f(conf)
 }
 
-// escape prefixed additional gelf fields.
-func (w *wrappedCore) escape(fields []zapcore.Field) []zapcore.Field {
+// escape prefixed additional gelf fields, expands Context and error fields, filters and redacts fields, full is true
+// when expanded verbose error may be sent as full_message.
+func (w *wrappedCore) escape(fields []zapcore.Field, full bool) []zapcore.Field {
 	if len(fields) == 0 {
 		return fields
 	}
 
 	var escaped = make([]zapcore.Field, 0, len(fields))
 	for _, field := range fields {
-		field.Key = escapeKey(field.Key)
-		escaped = append(escaped, field)
+		if cf, ok := field.Interface.(contextField); ok && field.Type == zapcore.SkipType {
+			// expanded fields are filtered and redacted in place, writes never pass reads
+			var start = len(escaped)
+			escaped = appendTrace(escaped, w.conf.traceExtractors, cf.ctx)
+
+			var expanded = escaped[start:]
+			escaped = escaped[:start]
+			for _, traced := range expanded {
+				if w.filter == nil || w.filter.allowed(traced) {
+					escaped = w.appendRedacted(escaped, traced)
+				}
+			}
+
+			continue
+		}
+
+		if w.filter != nil && !w.filter.allowed(field) {
+			continue
+		}
+
+		if w.conf.facilitySet() && strings.TrimLeft(field.Key, "_") == "facility" {
+			// facility options take precedence, duplicate keys are not sent
+			continue
+		}
+
+		if err, ok := field.Interface.(error); ok && field.Type == zapcore.ErrorType && w.conf.expandErrors {
+			escaped = w.appendError(escaped, field.Key, err, &full)
+			continue
+		}
+
+		escaped = w.appendRedacted(escaped, field)
 	}
 
 	return escaped
 }
 
-// escapeKey append prefix to additional field keys.
-func escapeKey(value string) string {
-	switch value {
-	case "id":
-		return "__id"
-	case "version", "host", "short_message", "full_message", "timestamp", "level":
-		return value
+// appendRedacted appends redacted field with escaped key.
+func (w *wrappedCore) appendRedacted(escaped []zapcore.Field, field zapcore.Field) []zapcore.Field {
+	if w.redactor != nil {
+		var ok bool
+		if field, ok = w.redactor.field(field); !ok {
+			return escaped
+		}
 	}
 
-	if len(value) == 0 {
-		return "_"
-	}
+	field.Key = escapeProtocolKey(w.conf.protocol, field.Key)
 
-	if value[0] == '_' {
-		return value
-	}
+	return append(escaped, field)
+}
 
-	return "_" + value
+// escapeKey append prefix to additional field keys of GELF 1.1.
+func escapeKey(value string) string {
+	return escapeProtocolKey(ProtocolVersion11, value)
 }
 
 // levelEncoder maps the zap log levels to the gelf levels.
@@ -505,6 +811,120 @@ func levelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
 	}
 }
 
+// newOptionConf create options with default values.
+func newOptionConf() optionConf {
+	return optionConf{
+		addr:             "127.0.0.1:12201",
+		host:             "localhost",
+		encoder:          NewEncoderConfig(),
+		version:          ProtocolVersion11,
+		protocol:         ProtocolVersion11,
+		enabler:          zap.NewAtomicLevel(),
+		chunkSize:        DefaultChunkSize,
+		writeSyncers:     make([]zapcore.WriteSyncer, 0, 8),
+		compressionType:  CompressionGzip,
+		compressionLevel: gzip.BestCompression,
+		summaryInterval:  DefaultSummaryInterval,
+		transport:        TransportUDP,
+		timeout:          DefaultTimeout,
+		traceExtractors:  []TraceExtractor{TraceparentExtractor()},
+	}
+}
+
+// newCore create core with options.
+func newCore(options []Option) (_ *wrappedCore, err error) {
+	var conf = newOptionConf()
+	for _, option := range options {
+		if err = option.apply(&conf); err != nil {
+			return nil, err
+		}
+	}
+
+	var w *writer
+	if w, err = newWriter(&conf); err != nil {
+		return nil, err
+	}
+
+	return newWrappedCore(&conf, w), nil
+}
+
+// newWrappedCore create core writing to w.
+func newWrappedCore(conf *optionConf, w *writer) *wrappedCore {
+	var ws zapcore.WriteSyncer = w
+	if len(conf.writeSyncers) > 0 {
+		var writers = append([]zapcore.WriteSyncer{w}, conf.writeSyncers...)
+		ws = zapcore.NewMultiWriteSyncer(writers...)
+	}
+
+	var encoder = conf.encoder
+	if conf.splitCaller || conf.legacy() {
+		// caller is sent as split fields
+		encoder.CallerKey = ""
+	}
+
+	var core = zapcore.NewCore(
+		zapcore.NewJSONEncoder(encoder),
+		ws,
+		conf.enabler,
+	)
+
+	core = core.With([]zapcore.Field{
+		zap.String("host", conf.host),
+		zap.String("version", conf.version),
+	})
+
+	var wc = &wrappedCore{
+		core:     core,
+		conf:     conf,
+		writer:   w,
+		redactor: newRedactor(conf),
+		filter:   newFieldFilter(conf),
+		dedupe:   newDeduplicator(conf),
+	}
+
+	// summary entries are sent through write, so they are redacted and observed as other entries
+	wc.sampler = newSampler(conf, wc.write, w.limiter)
+
+	return wc
+}
+
+// newWriter create GELF writer.
+func newWriter(conf *optionConf) (w *writer, err error) {
+	w = &writer{
+		chunkSize:        conf.chunkSize,
+		chunkDataSize:    conf.chunkSize - 12, // chunk size - chunk header size
+		compressionType:  conf.compressionType,
+		compressionLevel: conf.compressionLevel,
+		transport:        conf.transport,
+		stats:            &stats{},
+		limiter:          newRateLimiter(conf),
+		messageIDs:       conf.messageIDs,
+	}
+
+	if w.messageIDs == nil {
+		w.messageIDs = NewMessageIDGenerator()
+	}
+
+	w.observers = append([]Observer{w.stats}, conf.observers...)
+
+	switch conf.transport {
+	case TransportTCP, TransportTLS:
+		w.compressionType = CompressionNone
+	}
+
+	if w.sender = newSender(conf); w.sender == nil {
+		if w.conn, err = net.Dial("udp", conf.addr); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.compressWorkers > 0 {
+		w.pipeline = newPipeline(w, conf.compressWorkers)
+	}
+
+	return w, nil
+}
+
 // chunkCount calculate the number of GELF chunks.
 func (w *writer) chunkCount(b []byte) int {
 	lenB := len(b)
@@ -526,12 +946,10 @@ func (w *writer) writeChunked(count int, cBytes []byte) (n int, err error) {
 			make([]byte, 0, w.chunkSize),
 		)
 		nChunks   = uint8(count)
-		messageID = make([]byte, 8)
+		messageID [8]byte
 	)
 
-	if n, err = io.ReadFull(rand.Reader, messageID); err != nil || n != 8 {
-		return 0, fmt.Errorf("rand.Reader: %d/%s", n, err)
-	}
+	binary.BigEndian.PutUint64(messageID[:], w.messageIDs.NextID())
 
 	var (
 		off       int
@@ -548,7 +966,7 @@ func (w *writer) writeChunked(count int, cBytes []byte) (n int, err error) {
 
 		cBuf.Reset()
 		cBuf.Write(chunkedMagicBytes)
-		cBuf.Write(messageID)
+		cBuf.Write(messageID[:])
 		cBuf.WriteByte(i)
 		cBuf.WriteByte(nChunks)
 		cBuf.Write(cBytes[off : off+chunkLen])
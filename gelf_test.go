@@ -1,16 +1,20 @@
 package gelf_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
 	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
 )
 
 func TestAddr(t *testing.T) {
@@ -264,3 +268,88 @@ func TestCompressionLevel(t *testing.T) {
 	assert.Nil(t, err, "Unexpected error")
 	assert.Implements(t, (*zapcore.Core)(nil), core, "Expect zapcore.Core")
 }
+
+func TestOnError(t *testing.T) {
+	var (
+		errs    []error
+		entries []zapcore.Entry
+		output  bytes.Buffer
+	)
+
+	var core, err = gelf.NewCore(
+		gelf.ChunkSize(gelf.MinChunkSize),
+		gelf.CompressionType(gelf.CompressionNone),
+		gelf.OnError(func(err error, entry zapcore.Entry) {
+			errs = append(errs, err)
+			entries = append(entries, entry)
+		}),
+	)
+
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core, zap.ErrorOutput(zapcore.AddSync(&output)))
+	logger.With(zap.String("with", "field")).Info(strings.Repeat("x", gelf.MinChunkSize*gelf.MaxChunkCount))
+
+	assert.Len(t, errs, 1, "Expect delivery error")
+	assert.Len(t, entries, 1, "Expect failed entry")
+	assert.Equal(t, zap.InfoLevel, entries[0].Level)
+	assert.Zero(t, output.Len(), "Expect empty error output")
+}
+
+func TestWrite(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.Host("example.org"),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	zap.New(core, zap.AddCaller()).
+		Named("test").
+		With(zap.String("with", "field")).
+		Warn("message", zap.String("id", "an_id"), zap.Int("_count", 1))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Equal(t, "1.1", message["version"])
+	assert.Equal(t, "example.org", message["host"])
+	assert.Equal(t, "message", message["short_message"])
+	assert.Equal(t, float64(4), message["level"])
+	assert.Equal(t, "test", message["_logger"])
+	assert.Equal(t, "field", message["_with"])
+	assert.Equal(t, "an_id", message["__id"])
+	assert.Equal(t, float64(1), message["_count"])
+	assert.Contains(t, message["_caller"], "gelf_test.go")
+	assert.IsType(t, float64(0), message["timestamp"])
+}
+
+func TestNewWriter(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var w, err = gelf.NewWriter(
+		gelf.Addr(server.UDPAddr()),
+		gelf.ChunkSize(gelf.MinChunkSize),
+		gelf.CompressionType(gelf.CompressionNone),
+	)
+	assert.Nil(t, err, "Unexpected error")
+	defer w.Close()
+
+	var payload = `{"version":"1.1","host":"example.org","short_message":"` + strings.Repeat("x", gelf.MinChunkSize*2) + `"}`
+	_, err = io.WriteString(w, payload)
+	assert.Nil(t, err, "Unexpected error")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "example.org", messages[0]["host"])
+
+	w, err = gelf.NewWriter(gelf.Transport("quic"))
+	assert.Equal(t, gelf.ErrUnknownTransport, err)
+	assert.Nil(t, w, "Expected nil")
+}
@@ -0,0 +1,34 @@
+//go:build go1.18
+// +build go1.18
+
+package gelfdecode_test
+
+import (
+	"testing"
+
+	"github.com/snovichkov/zap-gelf/gelfdecode"
+)
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte(`{"version":"1.1","short_message":"hello"}`))
+	f.Add([]byte{0x1e, 0x0f, 1, 2, 3, 4, 5, 6, 7, 8, 0, 1, '{', '}'})
+	f.Add([]byte{0x1e, 0x0f, 1, 2, 3, 4, 5, 6, 7, 8, 0, 2, '{'})
+	f.Add([]byte{0x1f, 0x8b, 0x08, 0x00})
+	f.Add([]byte{0x78, 0x9c, 0x00})
+
+	var decoder, err = gelfdecode.NewDecoder(gelfdecode.MaxMemory(1 << 16))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, datagram []byte) {
+		var message, err = decoder.Decode(datagram)
+		if err != nil && message != nil {
+			t.Fatalf("message %v returned with error %v", message, err)
+		}
+
+		if decoder.Pending() > 1<<16 {
+			t.Fatalf("too many pending messages: %d", decoder.Pending())
+		}
+	})
+}
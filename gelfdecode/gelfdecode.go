@@ -0,0 +1,377 @@
+// Package gelfdecode decodes GELF messages from raw UDP datagrams and payloads.
+//
+// Decoder detects chunked messages by magic bytes 0x1e 0x0f and reassembles them by message ID, payloads compressed
+// by gzip or zlib are detected by their headers:
+//
+//	decoder, err := gelfdecode.NewDecoder()
+//	...
+//	for {
+//		n, _, err := conn.ReadFrom(buf)
+//		...
+//		message, err := decoder.Decode(buf[:n])
+//		if err != nil || message == nil {
+//			continue // invalid datagram or not all chunks received yet
+//		}
+//		...
+//	}
+package gelfdecode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"container/list"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxChunkCount maximal chunk per message count.
+	// See http://docs.graylog.org/en/2.4/pages/gelf.html.
+	MaxChunkCount = 128
+
+	// ChunkHeaderSize size of chunk header: magic bytes, message ID, sequence number and sequence count.
+	ChunkHeaderSize = 12
+
+	// DefaultTimeout is default time to wait for all chunks of message.
+	// See http://docs.graylog.org/en/2.4/pages/gelf.html.
+	DefaultTimeout = 5 * time.Second
+
+	// DefaultMaxMemory is default memory cap of not completed chunked messages.
+	DefaultMaxMemory = 32 << 20
+)
+
+type (
+	// Message decoded GELF message.
+	Message map[string]interface{}
+
+	// Option interface.
+	Option interface {
+		apply(conf *optionConf) error
+	}
+
+	// optionConf decoder options.
+	optionConf struct {
+		timeout   time.Duration
+		maxMemory int
+	}
+
+	// optionFunc wraps a func so it satisfies the Option interface.
+	optionFunc func(conf *optionConf) error
+
+	// Decoder decodes GELF datagrams, it is safe for concurrent use.
+	Decoder struct {
+		timeout   time.Duration
+		maxMemory int
+
+		mu       sync.Mutex
+		memory   int
+		order    *list.List
+		messages map[string]*list.Element
+	}
+
+	// chunked not completed chunked message.
+	chunked struct {
+		id       string
+		deadline time.Time
+		chunks   [][]byte
+		received int
+		size     int
+	}
+)
+
+var (
+	// ErrEmptyPayload triggered when payload is empty.
+	ErrEmptyPayload = errors.New("empty payload")
+
+	// ErrNotObject triggered when payload is not a JSON object.
+	ErrNotObject = errors.New("message is not an object")
+
+	// ErrChunkTooShort triggered when chunk is shorter than chunk header.
+	ErrChunkTooShort = errors.New("chunk too short")
+
+	// ErrInvalidChunkCount triggered when chunk count is zero or exceeds MaxChunkCount.
+	ErrInvalidChunkCount = errors.New("invalid chunk count")
+
+	// ErrInvalidSequence triggered when chunk sequence number is out of range.
+	ErrInvalidSequence = errors.New("invalid chunk sequence number")
+
+	// ErrChunkCountMismatch triggered when chunks of one message have different chunk count.
+	ErrChunkCountMismatch = errors.New("chunk count mismatch")
+
+	// ErrDuplicateChunk triggered when chunk with same sequence number received twice.
+	ErrDuplicateChunk = errors.New("duplicate chunk")
+
+	// ErrMemoryLimit triggered when chunked message exceeds memory cap.
+	ErrMemoryLimit = errors.New("chunked message exceeds memory limit")
+
+	// ErrPayloadTooLarge triggered when decompressed payload exceeds memory cap.
+	ErrPayloadTooLarge = errors.New("decompressed payload exceeds memory limit")
+
+	// ErrInvalidTimeout triggered when passed invalid timeout.
+	ErrInvalidTimeout = errors.New("invalid timeout")
+
+	// ErrInvalidMaxMemory triggered when passed invalid memory cap.
+	ErrInvalidMaxMemory = errors.New("invalid max memory")
+
+	// chunkedMagicBytes chunked message magic bytes.
+	chunkedMagicBytes = []byte{0x1e, 0x0f}
+)
+
+// NewDecoder decoder constructor.
+func NewDecoder(options ...Option) (*Decoder, error) {
+	var conf = optionConf{
+		timeout:   DefaultTimeout,
+		maxMemory: DefaultMaxMemory,
+	}
+
+	for _, option := range options {
+		if err := option.apply(&conf); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Decoder{
+		timeout:   conf.timeout,
+		maxMemory: conf.maxMemory,
+		order:     list.New(),
+		messages:  make(map[string]*list.Element),
+	}, nil
+}
+
+// Timeout set time to wait for all chunks of message.
+func Timeout(value time.Duration) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if value <= 0 {
+			return ErrInvalidTimeout
+		}
+
+		conf.timeout = value
+
+		return nil
+	})
+}
+
+// MaxMemory set memory cap in bytes of not completed chunked messages, oldest messages are dropped when cap exceeded.
+// Decompressed size of message is limited by the same cap.
+func MaxMemory(value int) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if value <= 0 {
+			return ErrInvalidMaxMemory
+		}
+
+		conf.maxMemory = value
+
+		return nil
+	})
+}
+
+// Decode decodes datagram, returns nil message when chunked message is not completed yet.
+func (d *Decoder) Decode(datagram []byte) (Message, error) {
+	var payload, err = d.Reassemble(datagram)
+	if err != nil || payload == nil {
+		return nil, err
+	}
+
+	return DecodePayload(payload)
+}
+
+// Reassemble returns decompressed JSON payload of datagram without decoding it, returns nil payload when chunked
+// message is not completed yet.
+func (d *Decoder) Reassemble(datagram []byte) (_ []byte, err error) {
+	if IsChunk(datagram) {
+		if datagram, err = d.reassemble(datagram, time.Now()); err != nil || datagram == nil {
+			return nil, err
+		}
+	}
+
+	if len(datagram) == 0 {
+		return nil, ErrEmptyPayload
+	}
+
+	return decompress(datagram, d.maxMemory)
+}
+
+// Pending returns count of not completed chunked messages.
+func (d *Decoder) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.messages)
+}
+
+// IsChunk returns true when datagram starts with chunked message magic bytes.
+func IsChunk(datagram []byte) bool {
+	return bytes.HasPrefix(datagram, chunkedMagicBytes)
+}
+
+// Decompress decompress gzip or zlib payload detected by header, other payloads returned as is. Decompressed payload
+// is limited by DefaultMaxMemory.
+func Decompress(payload []byte) ([]byte, error) {
+	return decompress(payload, DefaultMaxMemory)
+}
+
+// decompress decompress payload, returns ErrPayloadTooLarge when decompressed payload exceeds limit bytes.
+func decompress(payload []byte, limit int) (_ []byte, err error) {
+	var reader io.ReadCloser
+	switch {
+	case isGzip(payload):
+		reader, err = gzip.NewReader(bytes.NewReader(payload))
+	case isZlib(payload):
+		reader, err = zlib.NewReader(bytes.NewReader(payload))
+	default:
+		return payload, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	// limit decompressed size, small payload may expand to gigabytes
+	var data []byte
+	if data, err = ioutil.ReadAll(io.LimitReader(reader, int64(limit)+1)); err != nil {
+		return nil, err
+	}
+
+	if len(data) > limit {
+		return nil, ErrPayloadTooLarge
+	}
+
+	return data, nil
+}
+
+// DecodePayload decodes not chunked, optionally compressed, message payload.
+func DecodePayload(payload []byte) (_ Message, err error) {
+	if len(payload) == 0 {
+		return nil, ErrEmptyPayload
+	}
+
+	if payload, err = Decompress(payload); err != nil {
+		return nil, err
+	}
+
+	var message Message
+	if err = json.Unmarshal(payload, &message); err != nil {
+		return nil, err
+	}
+
+	if message == nil {
+		return nil, ErrNotObject
+	}
+
+	return message, nil
+}
+
+// apply implements Option.
+func (f optionFunc) apply(conf *optionConf) error {
+	return f(conf)
+}
+
+// reassemble collects chunk, returns payload when all chunks of message received.
+func (d *Decoder) reassemble(chunk []byte, now time.Time) ([]byte, error) {
+	if len(chunk) < ChunkHeaderSize {
+		return nil, ErrChunkTooShort
+	}
+
+	var (
+		id    = string(chunk[2:10])
+		seq   = int(chunk[10])
+		count = int(chunk[11])
+		data  = chunk[ChunkHeaderSize:]
+	)
+
+	if count == 0 || count > MaxChunkCount {
+		return nil, ErrInvalidChunkCount
+	}
+
+	if seq >= count {
+		return nil, ErrInvalidSequence
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expire(now)
+
+	if len(data) > d.maxMemory {
+		return nil, ErrMemoryLimit
+	}
+
+	var message *chunked
+	if el, ok := d.messages[id]; ok {
+		message = el.Value.(*chunked)
+	} else {
+		message = &chunked{
+			id:       id,
+			deadline: now.Add(d.timeout),
+			chunks:   make([][]byte, count),
+		}
+
+		d.messages[id] = d.order.PushBack(message)
+	}
+
+	if len(message.chunks) != count {
+		return nil, ErrChunkCountMismatch
+	}
+
+	if message.chunks[seq] != nil {
+		return nil, ErrDuplicateChunk
+	}
+
+	for d.memory+len(data) > d.maxMemory {
+		d.remove(d.order.Front())
+	}
+
+	if _, ok := d.messages[id]; !ok {
+		// message itself was evicted to fit memory cap
+		return nil, ErrMemoryLimit
+	}
+
+	// copy data, datagram buffer is usually reused by caller
+	message.chunks[seq] = append(make([]byte, 0, len(data)), data...)
+	message.received++
+	message.size += len(data)
+	d.memory += len(data)
+
+	if message.received < count {
+		return nil, nil
+	}
+
+	d.remove(d.messages[id])
+
+	return bytes.Join(message.chunks, nil), nil
+}
+
+// expire removes messages with exceeded deadline.
+func (d *Decoder) expire(now time.Time) {
+	for el := d.order.Front(); el != nil; el = d.order.Front() {
+		if now.Before(el.Value.(*chunked).deadline) {
+			return
+		}
+
+		d.remove(el)
+	}
+}
+
+// remove removes not completed message.
+func (d *Decoder) remove(el *list.Element) {
+	var message = d.order.Remove(el).(*chunked)
+	delete(d.messages, message.id)
+	d.memory -= message.size
+}
+
+// isGzip returns true when payload starts with gzip header.
+func isGzip(payload []byte) bool {
+	return len(payload) > 1 && payload[0] == 0x1f && payload[1] == 0x8b
+}
+
+// isZlib returns true when payload starts with zlib header.
+func isZlib(payload []byte) bool {
+	return len(payload) > 1 && payload[0]&0x0f == 8 && (uint16(payload[0])<<8|uint16(payload[1]))%31 == 0
+}
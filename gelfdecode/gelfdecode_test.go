@@ -0,0 +1,207 @@
+package gelfdecode_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snovichkov/zap-gelf/gelfdecode"
+)
+
+func TestDecodePayload(t *testing.T) {
+	var (
+		payload = []byte(`{"version":"1.1","short_message":"hello"}`)
+		gzipped bytes.Buffer
+		zlibbed bytes.Buffer
+		gw      = gzip.NewWriter(&gzipped)
+		zw      = zlib.NewWriter(&zlibbed)
+	)
+
+	_, _ = gw.Write(payload)
+	_, _ = zw.Write(payload)
+	assert.Nil(t, gw.Close(), "Unexpected error")
+	assert.Nil(t, zw.Close(), "Unexpected error")
+
+	for _, data := range [][]byte{payload, gzipped.Bytes(), zlibbed.Bytes()} {
+		var message, err = gelfdecode.DecodePayload(data)
+		assert.Nil(t, err, "Unexpected error")
+		assert.Equal(t, "hello", message["short_message"])
+	}
+
+	var invalid = [][]byte{nil, []byte("null"), []byte("[]"), {0x1f, 0x8b, 0x00}}
+	for _, data := range invalid {
+		var message, err = gelfdecode.DecodePayload(data)
+		assert.NotNil(t, err, "Expected error")
+		assert.Nil(t, message, "Expected nil")
+	}
+}
+
+func TestDecoderChunks(t *testing.T) {
+	var decoder, err = gelfdecode.NewDecoder()
+	assert.Nil(t, err, "Unexpected error")
+
+	var chunks = split("id000001", []byte(`{"short_message":"chunked"}`), 3)
+
+	var message gelfdecode.Message
+	message, err = decoder.Decode(chunks[2])
+	assert.Nil(t, err, "Unexpected error")
+	assert.Nil(t, message, "Expected nil")
+
+	message, err = decoder.Decode(chunks[0])
+	assert.Nil(t, err, "Unexpected error")
+	assert.Nil(t, message, "Expected nil")
+
+	_, err = decoder.Decode(chunks[0])
+	assert.Equal(t, gelfdecode.ErrDuplicateChunk, err)
+	assert.Equal(t, 1, decoder.Pending())
+
+	message, err = decoder.Decode(chunks[1])
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "chunked", message["short_message"])
+	assert.Equal(t, 0, decoder.Pending())
+}
+
+func TestDecoderReassemble(t *testing.T) {
+	var decoder, err = gelfdecode.NewDecoder()
+	assert.Nil(t, err, "Unexpected error")
+
+	var (
+		payload []byte
+		chunks  = split("id000002", []byte(`{"short_message":"raw"}`), 2)
+	)
+
+	payload, err = decoder.Reassemble(chunks[0])
+	assert.Nil(t, err, "Unexpected error")
+	assert.Nil(t, payload, "Expected nil")
+
+	payload, err = decoder.Reassemble(chunks[1])
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, `{"short_message":"raw"}`, string(payload))
+
+	_, err = decoder.Reassemble(nil)
+	assert.Equal(t, gelfdecode.ErrEmptyPayload, err)
+}
+
+func TestDecoderInvalidChunks(t *testing.T) {
+	var decoder, err = gelfdecode.NewDecoder()
+	assert.Nil(t, err, "Unexpected error")
+
+	var (
+		header = []byte{0x1e, 0x0f, 1, 2, 3, 4, 5, 6, 7, 8}
+		cases  = map[error][]byte{
+			gelfdecode.ErrChunkTooShort:     {0x1e, 0x0f, 1},
+			gelfdecode.ErrInvalidChunkCount: append(header[:10:10], 0, 0),
+			gelfdecode.ErrInvalidSequence:   append(header[:10:10], 2, 2),
+		}
+	)
+
+	_, err = decoder.Decode(append(header[:10:10], 0, 129))
+	assert.Equal(t, gelfdecode.ErrInvalidChunkCount, err)
+
+	for expected, chunk := range cases {
+		_, err = decoder.Decode(chunk)
+		assert.Equal(t, expected, err)
+	}
+
+	_, err = decoder.Decode(append(header[:10:10], 0, 2, '{'))
+	assert.Nil(t, err, "Unexpected error")
+
+	_, err = decoder.Decode(append(header[:10:10], 1, 3, '}'))
+	assert.Equal(t, gelfdecode.ErrChunkCountMismatch, err)
+}
+
+func TestDecoderTimeout(t *testing.T) {
+	var decoder, err = gelfdecode.NewDecoder(gelfdecode.Timeout(10 * time.Millisecond))
+	assert.Nil(t, err, "Unexpected error")
+
+	var chunks = split("id000001", []byte(`{"short_message":"expired"}`), 2)
+
+	_, err = decoder.Decode(chunks[0])
+	assert.Nil(t, err, "Unexpected error")
+
+	time.Sleep(20 * time.Millisecond)
+
+	var message gelfdecode.Message
+	message, err = decoder.Decode(chunks[1])
+	assert.Nil(t, err, "Unexpected error")
+	assert.Nil(t, message, "Expected nil")
+	assert.Equal(t, 1, decoder.Pending())
+}
+
+func TestDecoderMaxMemory(t *testing.T) {
+	var decoder, err = gelfdecode.NewDecoder(gelfdecode.MaxMemory(16))
+	assert.Nil(t, err, "Unexpected error")
+
+	var (
+		first  = split("id000001", []byte(`{"short_message":"first"}`), 3)
+		second = split("id000002", []byte(`{"short_message":"second"}`), 3)
+	)
+
+	_, err = decoder.Decode(first[0])
+	assert.Nil(t, err, "Unexpected error")
+
+	_, err = decoder.Decode(second[0])
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, 1, decoder.Pending(), "Expect oldest message evicted")
+
+	_, err = decoder.Decode(append(first[1][:gelfdecode.ChunkHeaderSize:gelfdecode.ChunkHeaderSize], make([]byte, 17)...))
+	assert.Equal(t, gelfdecode.ErrMemoryLimit, err)
+
+	var _, optErr = gelfdecode.NewDecoder(gelfdecode.MaxMemory(0))
+	assert.Equal(t, gelfdecode.ErrInvalidMaxMemory, optErr)
+
+	_, optErr = gelfdecode.NewDecoder(gelfdecode.Timeout(0))
+	assert.Equal(t, gelfdecode.ErrInvalidTimeout, optErr)
+}
+
+// split split payload to count chunks.
+func split(id string, payload []byte, count int) [][]byte {
+	var (
+		chunks = make([][]byte, 0, count)
+		size   = (len(payload) + count - 1) / count
+	)
+
+	for i := 0; i < count; i++ {
+		var end = (i + 1) * size
+		if end > len(payload) {
+			end = len(payload)
+		}
+
+		var chunk = append([]byte{0x1e, 0x0f}, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunks = append(chunks, append(chunk, payload[i*size:end]...))
+	}
+
+	return chunks
+}
+
+func TestDecompressLimit(t *testing.T) {
+	var (
+		bomb bytes.Buffer
+		gw   = gzip.NewWriter(&bomb)
+	)
+
+	_, _ = gw.Write(bytes.Repeat([]byte{' '}, 4096))
+	assert.Nil(t, gw.Close(), "Unexpected error")
+
+	var decoder, err = gelfdecode.NewDecoder(gelfdecode.MaxMemory(1024))
+	assert.Nil(t, err, "Unexpected error")
+
+	var payload []byte
+	payload, err = decoder.Reassemble(bomb.Bytes())
+	assert.Equal(t, gelfdecode.ErrPayloadTooLarge, err)
+	assert.Nil(t, payload, "Expected nil")
+
+	bomb.Reset()
+	gw.Reset(&bomb)
+	_, _ = gw.Write(bytes.Repeat([]byte{' '}, gelfdecode.DefaultMaxMemory+1))
+	assert.Nil(t, gw.Close(), "Unexpected error")
+
+	payload, err = gelfdecode.Decompress(bomb.Bytes())
+	assert.Equal(t, gelfdecode.ErrPayloadTooLarge, err)
+	assert.Nil(t, payload, "Expected nil")
+}
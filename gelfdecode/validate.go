@@ -0,0 +1,101 @@
+package gelfdecode
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+type (
+	// Violation describes GELF specification violation of message.
+	Violation struct {
+		// Key message field key, empty for message level violations.
+		Key string
+
+		// Reason violation description.
+		Reason string
+	}
+)
+
+var (
+	// requiredKeys keys required by GELF specification.
+	// See http://docs.graylog.org/en/2.4/pages/gelf.html.
+	requiredKeys = []string{"version", "host", "short_message"}
+
+	// specKeys keys defined by GELF specification, other keys should be additional fields prefixed by underscore.
+	specKeys = map[string]struct{}{
+		"version":       {},
+		"host":          {},
+		"short_message": {},
+		"full_message":  {},
+		"timestamp":     {},
+		"level":         {},
+		"facility":      {},
+		"line":          {},
+		"file":          {},
+	}
+
+	// keyRegexp allowed additional field key format.
+	keyRegexp = regexp.MustCompile(`^[\w.\-]*$`)
+)
+
+// Validate checks message against GELF specification, returns violations sorted by key.
+func Validate(message Message) []Violation {
+	var violations []Violation
+	for _, key := range requiredKeys {
+		if _, ok := message[key]; !ok {
+			violations = append(violations, Violation{Key: key, Reason: "missing required field"})
+		}
+	}
+
+	if value, ok := message["short_message"].(string); ok && value == "" {
+		violations = append(violations, Violation{Key: "short_message", Reason: "empty required field"})
+	}
+
+	if value, ok := message["level"]; ok {
+		if number, isNumber := value.(float64); !isNumber || number < 0 || number > 7 || number != float64(int(number)) {
+			violations = append(violations, Violation{Key: "level", Reason: "level should be syslog level from 0 to 7"})
+		}
+	}
+
+	if value, ok := message["timestamp"]; ok {
+		if _, isNumber := value.(float64); !isNumber {
+			violations = append(violations, Violation{Key: "timestamp", Reason: "timestamp should be a number"})
+		}
+	}
+
+	for key, value := range message {
+		if _, ok := specKeys[key]; ok {
+			continue
+		}
+
+		switch {
+		case key == "_id":
+			violations = append(violations, Violation{Key: key, Reason: "additional field _id is not allowed"})
+		case len(key) < 2 || key[0] != '_':
+			violations = append(violations, Violation{Key: key, Reason: "additional field should be prefixed by underscore"})
+		case !keyRegexp.MatchString(key):
+			violations = append(violations, Violation{Key: key, Reason: "illegal characters in field key"})
+		}
+
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			violations = append(violations, Violation{Key: key, Reason: "nested values are not allowed"})
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Key < violations[j].Key
+	})
+
+	return violations
+}
+
+// String implementation of fmt.Stringer.
+func (v Violation) String() string {
+	if v.Key == "" {
+		return v.Reason
+	}
+
+	return fmt.Sprintf("%s: %s", v.Key, v.Reason)
+}
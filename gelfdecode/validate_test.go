@@ -0,0 +1,55 @@
+package gelfdecode_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snovichkov/zap-gelf/gelfdecode"
+)
+
+func TestValidate(t *testing.T) {
+	var valid = gelfdecode.Message{
+		"version":       "1.1",
+		"host":          "localhost",
+		"short_message": "hello",
+		"level":         float64(6),
+		"timestamp":     1500000000.5,
+		"_user":         "alice",
+		"_request.id":   "42",
+	}
+
+	assert.Empty(t, gelfdecode.Validate(valid))
+
+	var invalid = gelfdecode.Message{
+		"short_message": "",
+		"level":         float64(9),
+		"timestamp":     "now",
+		"user":          "alice",
+		"_id":           "1",
+		"_bad key":      "value",
+		"_nested":       map[string]interface{}{"key": "value"},
+	}
+
+	assert.Equal(t, []string{
+		"_bad key: illegal characters in field key",
+		"_id: additional field _id is not allowed",
+		"_nested: nested values are not allowed",
+		"host: missing required field",
+		"level: level should be syslog level from 0 to 7",
+		"short_message: empty required field",
+		"timestamp: timestamp should be a number",
+		"user: additional field should be prefixed by underscore",
+		"version: missing required field",
+	}, strings(gelfdecode.Validate(invalid)))
+}
+
+// strings returns violations as strings.
+func strings(violations []gelfdecode.Violation) []string {
+	var result = make([]string, 0, len(violations))
+	for _, violation := range violations {
+		result = append(result, violation.String())
+	}
+
+	return result
+}
@@ -0,0 +1,428 @@
+// Package gelfgrpc logs gRPC calls through GELF core backed zap logger.
+//
+// Each call is logged as single entry with _grpc_method, _grpc_kind, _grpc_side, _grpc_code, _duration, _peer,
+// _request_size and _response_size fields, streaming calls also have _received_messages and _sent_messages fields.
+// The level is chosen by status code:
+//
+//	core, err := gelf.NewCore(gelf.Addr("graylog:12201"))
+//	...
+//	var logger = zap.New(core)
+//	var server = grpc.NewServer(
+//		grpc.UnaryInterceptor(gelfgrpc.UnaryServerInterceptor(logger)),
+//		grpc.StreamInterceptor(gelfgrpc.StreamServerInterceptor(logger)),
+//	)
+package gelfgrpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	gelf "github.com/snovichkov/zap-gelf"
+)
+
+const (
+	// KindUnary unary call kind.
+	KindUnary = "unary"
+
+	// KindClientStream client streaming call kind.
+	KindClientStream = "client_stream"
+
+	// KindServerStream server streaming call kind.
+	KindServerStream = "server_stream"
+
+	// KindBidiStream bidirectional streaming call kind.
+	KindBidiStream = "bidi_stream"
+
+	// SideServer server side of call.
+	SideServer = "server"
+
+	// SideClient client side of call.
+	SideClient = "client"
+)
+
+type (
+	// Option interface.
+	Option interface {
+		apply(conf *optionConf)
+	}
+
+	// optionConf interceptor options.
+	optionConf struct {
+		level func(code codes.Code) zapcore.Level
+		skip  func(fullMethod string) bool
+	}
+
+	// optionFunc wraps a func so it satisfies the Option interface.
+	optionFunc func(conf *optionConf)
+
+	// call logs single call, it is safe for concurrent sending and receiving of stream messages.
+	call struct {
+		conf         *optionConf
+		ctx          context.Context
+		method       string
+		kind         string
+		side         string
+		start        time.Time
+		peer         *peer.Peer
+		mu           sync.Mutex
+		logger       *zap.Logger
+		received     int
+		sent         int
+		requestSize  int
+		responseSize int
+	}
+
+	// serverStream counts messages of server stream.
+	serverStream struct {
+		grpc.ServerStream
+		ctx  context.Context
+		call *call
+	}
+
+	// clientStream counts messages of client stream and logs call when stream is finished.
+	clientStream struct {
+		grpc.ClientStream
+		call *call
+	}
+)
+
+// UnaryServerInterceptor returns interceptor logging unary calls to logger.
+//
+// W3C traceparent of incoming metadata is stored in call context by gelf.WithTraceparent, so entries of logger with
+// gelf.Context field of call context are linked to the trace.
+func UnaryServerInterceptor(logger *zap.Logger, options ...Option) grpc.UnaryServerInterceptor {
+	var conf = newOptionConf(options)
+	return func(
+		ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if conf.skip != nil && conf.skip(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx = withTraceparent(ctx)
+
+		var c = newCall(conf, logger, ctx, info.FullMethod, KindUnary, SideServer)
+		c.receive(req)
+
+		var resp, err = handler(ctx, req)
+		if err == nil {
+			c.send(resp)
+		}
+
+		c.log(err)
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns interceptor logging streaming calls to logger.
+func StreamServerInterceptor(logger *zap.Logger, options ...Option) grpc.StreamServerInterceptor {
+	var conf = newOptionConf(options)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if conf.skip != nil && conf.skip(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		var (
+			ctx = withTraceparent(ss.Context())
+			c   = newCall(conf, logger, ctx, info.FullMethod, streamKind(info.IsClientStream, info.IsServerStream), SideServer)
+			err = handler(srv, &serverStream{ServerStream: ss, ctx: ctx, call: c})
+		)
+
+		c.log(err)
+
+		return err
+	}
+}
+
+// UnaryClientInterceptor returns interceptor logging unary calls to logger.
+func UnaryClientInterceptor(logger *zap.Logger, options ...Option) grpc.UnaryClientInterceptor {
+	var conf = newOptionConf(options)
+	return func(
+		ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if conf.skip != nil && conf.skip(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		var c = newCall(conf, logger, ctx, method, KindUnary, SideClient)
+		c.send(req)
+
+		var err = invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(c.peer))...)
+		if err == nil {
+			c.receive(reply)
+		}
+
+		c.log(err)
+
+		return err
+	}
+}
+
+// StreamClientInterceptor returns interceptor logging streaming calls to logger, call is logged when stream is
+// finished by received error or io.EOF.
+func StreamClientInterceptor(logger *zap.Logger, options ...Option) grpc.StreamClientInterceptor {
+	var conf = newOptionConf(options)
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if conf.skip != nil && conf.skip(method) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		var (
+			c       = newCall(conf, logger, ctx, method, streamKind(desc.ClientStreams, desc.ServerStreams), SideClient)
+			cs, err = streamer(ctx, desc, cc, method, append(opts, grpc.Peer(c.peer))...)
+		)
+
+		if err != nil {
+			c.log(err)
+			return nil, err
+		}
+
+		return &clientStream{ClientStream: cs, call: c}, nil
+	}
+}
+
+// CodeLevel returns InfoLevel for OK, WarnLevel for client errors and ErrorLevel for server errors.
+func CodeLevel(code codes.Code) zapcore.Level {
+	switch code {
+	case codes.OK:
+		return zapcore.InfoLevel
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.PermissionDenied,
+		codes.Unauthenticated, codes.ResourceExhausted, codes.FailedPrecondition, codes.Aborted, codes.OutOfRange:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// LevelFunc set func choosing level by status code, CodeLevel by default.
+func LevelFunc(value func(code codes.Code) zapcore.Level) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.level = value
+	})
+}
+
+// Skip set func choosing methods which calls are not logged, e.g. health checks.
+func Skip(value func(fullMethod string) bool) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.skip = value
+	})
+}
+
+// Context returns context with traceparent of call.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg implementation of grpc.ServerStream.
+func (s *serverStream) RecvMsg(m interface{}) error {
+	var err = s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.call.receive(m)
+	}
+
+	return err
+}
+
+// SendMsg implementation of grpc.ServerStream.
+func (s *serverStream) SendMsg(m interface{}) error {
+	var err = s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.call.send(m)
+	}
+
+	return err
+}
+
+// RecvMsg implementation of grpc.ClientStream.
+func (s *clientStream) RecvMsg(m interface{}) error {
+	var err = s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.call.log(err)
+		return err
+	}
+
+	s.call.receive(m)
+	if !s.call.isServerStream() {
+		// single response of not server streaming call finishes stream
+		s.call.log(nil)
+	}
+
+	return nil
+}
+
+// SendMsg implementation of grpc.ClientStream.
+func (s *clientStream) SendMsg(m interface{}) error {
+	var err = s.ClientStream.SendMsg(m)
+	if err == nil {
+		s.call.send(m)
+	}
+
+	return err
+}
+
+// apply implements Option.
+func (f optionFunc) apply(conf *optionConf) {
+	f(conf)
+}
+
+// newOptionConf create options.
+func newOptionConf(options []Option) *optionConf {
+	var conf = &optionConf{
+		level: CodeLevel,
+	}
+
+	for _, option := range options {
+		option.apply(conf)
+	}
+
+	return conf
+}
+
+// newCall create call.
+func newCall(conf *optionConf, logger *zap.Logger, ctx context.Context, method, kind, side string) *call {
+	var c = &call{
+		conf:   conf,
+		logger: logger,
+		ctx:    ctx,
+		method: method,
+		kind:   kind,
+		side:   side,
+		start:  time.Now(),
+		peer:   &peer.Peer{},
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && side == SideServer {
+		c.peer = p
+	}
+
+	return c
+}
+
+// receive counts received message.
+func (c *call) receive(m interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.received++
+	if c.side == SideServer {
+		c.requestSize += size(m)
+	} else {
+		c.responseSize += size(m)
+	}
+}
+
+// send counts sent message.
+func (c *call) send(m interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sent++
+	if c.side == SideServer {
+		c.responseSize += size(m)
+	} else {
+		c.requestSize += size(m)
+	}
+}
+
+// isServerStream returns true when server sends stream of messages.
+func (c *call) isServerStream() bool {
+	return c.kind == KindServerStream || c.kind == KindBidiStream
+}
+
+// log writes call entry once, io.EOF received by client stream is treated as OK status.
+func (c *call) log(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.logger == nil {
+		return
+	}
+
+	var logger = c.logger
+	c.logger = nil
+
+	if err == io.EOF && c.side == SideClient {
+		err = nil
+	}
+
+	var code = status.Code(err)
+
+	var ce = logger.Check(c.conf.level(code), c.method+" "+code.String())
+	if ce == nil {
+		return
+	}
+
+	var fields = make([]zapcore.Field, 0, 13)
+	fields = append(
+		fields,
+		zap.String("grpc_method", c.method),
+		zap.String("grpc_kind", c.kind),
+		zap.String("grpc_side", c.side),
+		zap.String("grpc_code", code.String()),
+		zap.Duration("duration", time.Since(c.start)),
+		zap.Int("request_size", c.requestSize),
+		zap.Int("response_size", c.responseSize),
+	)
+
+	if c.kind != KindUnary {
+		fields = append(fields, zap.Int("received_messages", c.received), zap.Int("sent_messages", c.sent))
+	}
+
+	if c.peer.Addr != nil {
+		fields = append(fields, zap.String("peer", c.peer.Addr.String()))
+	}
+
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+
+	ce.Write(append(fields, gelf.Context(c.ctx))...)
+}
+
+// withTraceparent stores traceparent of incoming metadata in context.
+func withTraceparent(ctx context.Context) context.Context {
+	if values := metadata.ValueFromIncomingContext(ctx, "traceparent"); len(values) > 0 {
+		return gelf.WithTraceparent(ctx, values[0])
+	}
+
+	return ctx
+}
+
+// streamKind returns kind of streaming call.
+func streamKind(clientStream, serverStream bool) string {
+	switch {
+	case clientStream && serverStream:
+		return KindBidiStream
+	case clientStream:
+		return KindClientStream
+	case serverStream:
+		return KindServerStream
+	default:
+		return KindUnary
+	}
+}
+
+// size returns size of protobuf message, 0 for other messages.
+func size(m interface{}) int {
+	if message, ok := m.(proto.Message); ok {
+		return proto.Size(message)
+	}
+
+	return 0
+}
@@ -0,0 +1,150 @@
+package gelfgrpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelfgrpc"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestInterceptors(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(gelf.Addr(server.UDPAddr()))
+	assert.Nil(t, err, "Unexpected error")
+
+	var (
+		logger   = zap.New(core)
+		listener = bufconn.Listen(1 << 20)
+		checker  = health.NewServer()
+		srv      = grpc.NewServer(
+			grpc.UnaryInterceptor(gelfgrpc.UnaryServerInterceptor(logger)),
+			grpc.StreamInterceptor(gelfgrpc.StreamServerInterceptor(logger)),
+		)
+	)
+
+	healthpb.RegisterHealthServer(srv, checker)
+	checker.SetServingStatus("test", healthpb.HealthCheckResponse_SERVING)
+
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	defer srv.Stop()
+
+	var conn *grpc.ClientConn
+	conn, err = grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(gelfgrpc.UnaryClientInterceptor(logger)),
+		grpc.WithStreamInterceptor(gelfgrpc.StreamClientInterceptor(logger)),
+	)
+	assert.Nil(t, err, "Unexpected error")
+	defer conn.Close()
+
+	var (
+		client = healthpb.NewHealthClient(conn)
+		ctx    = metadata.AppendToOutgoingContext(
+			context.Background(), "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		)
+	)
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "test"})
+	assert.Nil(t, err, "Unexpected error")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var sides = make(map[string]gelftest.Message)
+	for _, message := range messages {
+		sides[message["_grpc_side"].(string)] = message
+	}
+
+	var message = sides[gelfgrpc.SideServer]
+	assert.Equal(t, "/grpc.health.v1.Health/Check OK", message["short_message"])
+	assert.Equal(t, float64(6), message["level"])
+	assert.Equal(t, "/grpc.health.v1.Health/Check", message["_grpc_method"])
+	assert.Equal(t, gelfgrpc.KindUnary, message["_grpc_kind"])
+	assert.Equal(t, "OK", message["_grpc_code"])
+	assert.Equal(t, float64(6), message["_request_size"])
+	assert.Equal(t, float64(2), message["_response_size"])
+	assert.Equal(t, "bufconn", message["_peer"])
+	assert.IsType(t, float64(0), message["_duration"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", message["_trace_id"])
+
+	message = sides[gelfgrpc.SideClient]
+	assert.Equal(t, float64(6), message["_request_size"])
+	assert.Equal(t, float64(2), message["_response_size"])
+	assert.Equal(t, "bufconn", message["_peer"])
+
+	server.Reset()
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	for _, message := range messages {
+		assert.Equal(t, float64(4), message["level"])
+		assert.Equal(t, "NotFound", message["_grpc_code"])
+		assert.Contains(t, message["_error"], "unknown service")
+	}
+
+	server.Reset()
+
+	var (
+		stream           healthpb.Health_WatchClient
+		watchCtx, cancel = context.WithCancel(context.Background())
+	)
+
+	stream, err = client.Watch(watchCtx, &healthpb.HealthCheckRequest{Service: "test"})
+	assert.Nil(t, err, "Unexpected error")
+
+	var resp *healthpb.HealthCheckResponse
+	resp, err = stream.Recv()
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	// cancel finishes watch stream on both sides
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	for _, message := range messages {
+		assert.Equal(t, gelfgrpc.KindServerStream, message["_grpc_kind"])
+		assert.Equal(t, "/grpc.health.v1.Health/Watch", message["_grpc_method"])
+		assert.True(t, message["_sent_messages"].(float64) >= 1, "Expected sent messages")
+	}
+}
+
+func TestCodeLevel(t *testing.T) {
+	assert.Equal(t, zapcore.InfoLevel, gelfgrpc.CodeLevel(codes.OK))
+	assert.Equal(t, zapcore.WarnLevel, gelfgrpc.CodeLevel(codes.NotFound))
+	assert.Equal(t, zapcore.ErrorLevel, gelfgrpc.CodeLevel(codes.Internal))
+	assert.Equal(t, zapcore.ErrorLevel, gelfgrpc.CodeLevel(codes.Unknown))
+}
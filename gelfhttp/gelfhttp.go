@@ -0,0 +1,284 @@
+// Package gelfhttp logs HTTP requests through GELF core backed zap logger.
+//
+// Each request is logged as single entry with _method, _path, _status, _bytes, _duration, _remote_addr,
+// _user_agent and _request_id fields, the level is chosen by status class:
+//
+//	core, err := gelf.NewCore(gelf.Addr("graylog:12201"))
+//	...
+//	var handler = gelfhttp.Middleware(zap.New(core), gelfhttp.Headers("X-Forwarded-For"))(mux)
+//
+// When handler panics the request is logged with status 500 and _panic field before the panic is propagated.
+package gelfhttp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+)
+
+const (
+	// DefaultRequestIDHeader is default header of request ID.
+	DefaultRequestIDHeader = "X-Request-Id"
+
+	// Redacted replaces values of redacted headers and query parameters.
+	Redacted = "[REDACTED]"
+)
+
+type (
+	// Option interface.
+	Option interface {
+		apply(conf *optionConf)
+	}
+
+	// optionConf middleware options.
+	optionConf struct {
+		requestIDHeader string
+		headers         []string
+		queryParams     []string
+		redact          map[string]struct{}
+		level           func(status int) zapcore.Level
+		skip            func(r *http.Request) bool
+	}
+
+	// optionFunc wraps a func so it satisfies the Option interface.
+	optionFunc func(conf *optionConf)
+
+	// responseWriter records status and size of response.
+	responseWriter struct {
+		http.ResponseWriter
+		status      int
+		bytes       int
+		wroteHeader bool
+	}
+)
+
+var (
+	// errHijackNotSupported triggered when underlying response writer does not implement http.Hijacker.
+	errHijackNotSupported = errors.New("response writer does not implement http.Hijacker")
+
+	// Ensure *responseWriter implements http.Flusher.
+	_ http.Flusher = (*responseWriter)(nil)
+
+	// Ensure *responseWriter implements http.Hijacker.
+	_ http.Hijacker = (*responseWriter)(nil)
+)
+
+// Middleware returns middleware logging requests to logger.
+//
+// W3C traceparent header is stored in request context by gelf.WithTraceparent, so entries of logger with
+// gelf.Context field of request context are linked to the trace.
+func Middleware(logger *zap.Logger, options ...Option) func(http.Handler) http.Handler {
+	var conf = optionConf{
+		requestIDHeader: DefaultRequestIDHeader,
+		redact:          make(map[string]struct{}),
+		level:           StatusLevel,
+	}
+
+	for _, option := range options {
+		option.apply(&conf)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if conf.skip != nil && conf.skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if traceparent := r.Header.Get("Traceparent"); traceparent != "" {
+				r = r.WithContext(gelf.WithTraceparent(r.Context(), traceparent))
+			}
+
+			var (
+				start = time.Now()
+				rw    = &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			)
+
+			defer func() {
+				var recovered = recover()
+				if recovered != nil {
+					rw.status = http.StatusInternalServerError
+				}
+
+				conf.log(logger, r, rw, time.Since(start), recovered)
+
+				if recovered != nil {
+					panic(recovered)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// StatusLevel returns ErrorLevel for 5xx, WarnLevel for 4xx and InfoLevel for other statuses.
+func StatusLevel(status int) zapcore.Level {
+	switch {
+	case status >= 500:
+		return zapcore.ErrorLevel
+	case status >= 400:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// RequestIDHeader set header of request ID, DefaultRequestIDHeader by default.
+func RequestIDHeader(value string) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.requestIDHeader = value
+	})
+}
+
+// Headers set allowlist of request headers logged as _header.<lowercase name> fields.
+func Headers(value ...string) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.headers = value
+	})
+}
+
+// QueryParams set allowlist of query parameters logged as _query.<name> fields.
+func QueryParams(value ...string) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.queryParams = value
+	})
+}
+
+// Redact set headers and query parameters which values are logged as Redacted, names are case insensitive.
+func Redact(value ...string) Option {
+	return optionFunc(func(conf *optionConf) {
+		for _, name := range value {
+			conf.redact[strings.ToLower(name)] = struct{}{}
+		}
+	})
+}
+
+// LevelFunc set func choosing level by response status, StatusLevel by default.
+func LevelFunc(value func(status int) zapcore.Level) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.level = value
+	})
+}
+
+// Skip set func choosing requests which are not logged, e.g. health checks.
+func Skip(value func(r *http.Request) bool) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.skip = value
+	})
+}
+
+// WriteHeader implementation of http.ResponseWriter.
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implementation of http.ResponseWriter.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	var n, err = w.ResponseWriter.Write(b)
+	w.wroteHeader = true
+	w.bytes += n
+
+	return n, err
+}
+
+// Flush implementation of http.Flusher.
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implementation of http.Hijacker.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+
+	return nil, nil, errHijackNotSupported
+}
+
+// Unwrap returns underlying response writer, used by http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// apply implements Option.
+func (f optionFunc) apply(conf *optionConf) {
+	f(conf)
+}
+
+// value returns value or Redacted when name is redacted.
+func (conf *optionConf) value(name, value string) string {
+	if _, ok := conf.redact[strings.ToLower(name)]; ok {
+		return Redacted
+	}
+
+	return value
+}
+
+// log writes request entry, recovered is value of handler panic or nil.
+func (conf *optionConf) log(
+	logger *zap.Logger,
+	r *http.Request,
+	rw *responseWriter,
+	duration time.Duration,
+	recovered interface{},
+) {
+	var ce = logger.Check(conf.level(rw.status), r.Method+" "+r.URL.Path+" "+strconv.Itoa(rw.status))
+	if ce == nil {
+		return
+	}
+
+	var fields = make([]zapcore.Field, 0, 11+len(conf.headers)+len(conf.queryParams))
+	fields = append(
+		fields,
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.Int("status", rw.status),
+		zap.Int("bytes", rw.bytes),
+		zap.Duration("duration", duration),
+		zap.String("remote_addr", r.RemoteAddr),
+		zap.String("user_agent", r.UserAgent()),
+	)
+
+	if recovered != nil {
+		fields = append(fields, zap.String("panic", fmt.Sprint(recovered)))
+	}
+
+	if requestID := r.Header.Get(conf.requestIDHeader); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+
+	for _, name := range conf.headers {
+		if value := r.Header.Get(name); value != "" {
+			fields = append(fields, zap.String("header."+strings.ToLower(name), conf.value(name, value)))
+		}
+	}
+
+	if len(conf.queryParams) > 0 {
+		var query = r.URL.Query()
+		for _, name := range conf.queryParams {
+			if value := query.Get(name); value != "" {
+				fields = append(fields, zap.String("query."+name, conf.value(name, value)))
+			}
+		}
+	}
+
+	ce.Write(append(fields, gelf.Context(r.Context()))...)
+}
@@ -0,0 +1,120 @@
+package gelfhttp_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelfhttp"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestMiddleware(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(gelf.Addr(server.UDPAddr()))
+	assert.Nil(t, err, "Unexpected error")
+
+	var handler = gelfhttp.Middleware(
+		zap.New(core),
+		gelfhttp.Headers("X-Forwarded-For", "Authorization"),
+		gelfhttp.QueryParams("page", "token"),
+		gelfhttp.Redact("authorization", "token"),
+		gelfhttp.Skip(func(r *http.Request) bool {
+			return r.URL.Path == "/health"
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/failed":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = io.WriteString(w, "hello")
+		}
+	}))
+
+	var req = httptest.NewRequest(http.MethodGet, "/users?page=2&token=secret&other=1", nil)
+	req.Header.Set("User-Agent", "test")
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	for _, r := range []*http.Request{
+		req,
+		httptest.NewRequest(http.MethodGet, "/health", nil),
+		httptest.NewRequest(http.MethodPost, "/missing", nil),
+		httptest.NewRequest(http.MethodDelete, "/failed", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(3, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Equal(t, "GET /users 200", message["short_message"])
+	assert.Equal(t, float64(6), message["level"])
+	assert.Equal(t, "GET", message["_method"])
+	assert.Equal(t, "/users", message["_path"])
+	assert.Equal(t, float64(200), message["_status"])
+	assert.Equal(t, float64(5), message["_bytes"])
+	assert.IsType(t, float64(0), message["_duration"])
+	assert.Equal(t, "192.0.2.1:1234", message["_remote_addr"])
+	assert.Equal(t, "test", message["_user_agent"])
+	assert.Equal(t, "req-1", message["_request_id"])
+	assert.Equal(t, "10.0.0.1", message["_header.x-forwarded-for"])
+	assert.Equal(t, gelfhttp.Redacted, message["_header.authorization"])
+	assert.Equal(t, "2", message["_query.page"])
+	assert.Equal(t, gelfhttp.Redacted, message["_query.token"])
+	assert.NotContains(t, message, "_query.other")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", message["_trace_id"])
+
+	assert.Equal(t, "POST /missing 404", messages[1]["short_message"])
+	assert.Equal(t, float64(4), messages[1]["level"])
+	assert.Equal(t, "DELETE /failed 500", messages[2]["short_message"])
+	assert.Equal(t, float64(3), messages[2]["level"])
+}
+
+func TestStatusLevel(t *testing.T) {
+	assert.Equal(t, zapcore.InfoLevel, gelfhttp.StatusLevel(http.StatusNoContent))
+	assert.Equal(t, zapcore.InfoLevel, gelfhttp.StatusLevel(http.StatusFound))
+	assert.Equal(t, zapcore.WarnLevel, gelfhttp.StatusLevel(http.StatusTooManyRequests))
+	assert.Equal(t, zapcore.ErrorLevel, gelfhttp.StatusLevel(http.StatusBadGateway))
+}
+
+func TestMiddlewarePanic(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(gelf.Addr(server.UDPAddr()))
+	assert.Nil(t, err, "Unexpected error")
+
+	var handler = gelfhttp.Middleware(zap.New(core))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("broken")
+	}))
+
+	assert.PanicsWithValue(t, "broken", func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Equal(t, "GET /panic 500", message["short_message"])
+	assert.Equal(t, float64(3), message["level"])
+	assert.Equal(t, float64(500), message["_status"])
+	assert.Equal(t, "broken", message["_panic"])
+}
@@ -0,0 +1,30 @@
+// Package gelfotel extracts trace context of OpenTelemetry spans for GELF cores.
+package gelfotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	gelf "github.com/snovichkov/zap-gelf"
+)
+
+// Extractor returns extractor of OpenTelemetry span context:
+//
+//	core, err := gelf.NewCore(gelf.TraceExtractors(gelfotel.Extractor(), gelf.TraceparentExtractor()))
+//	...
+//	logger.Info("handled", gelf.Context(ctx))
+func Extractor() gelf.TraceExtractor {
+	return gelf.TraceExtractorFunc(func(ctx context.Context) (gelf.TraceContext, bool) {
+		var sc = trace.SpanContextFromContext(ctx)
+		if !sc.IsValid() {
+			return gelf.TraceContext{}, false
+		}
+
+		return gelf.TraceContext{
+			TraceID: sc.TraceID().String(),
+			SpanID:  sc.SpanID().String(),
+			Flags:   byte(sc.TraceFlags()),
+		}, true
+	})
+}
@@ -0,0 +1,44 @@
+package gelfotel_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelfotel"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestExtractor(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.TraceExtractors(gelfotel.Extractor()),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var ctx = trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	var logger = zap.New(core)
+	logger.Info("traced", gelf.Context(ctx))
+	logger.Info("not traced", gelf.Context(context.Background()))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", messages[0]["_trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", messages[0]["_span_id"])
+	assert.Equal(t, "01", messages[0]["_trace_flags"])
+	assert.NotContains(t, messages[1], "_trace_id")
+}
@@ -0,0 +1,21 @@
+module github.com/snovichkov/zap-gelf/gelfotel
+
+go 1.21
+
+replace github.com/snovichkov/zap-gelf => ../
+
+require (
+	github.com/snovichkov/zap-gelf v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.9.0
+	go.opentelemetry.io/otel/trace v1.28.0
+	go.uber.org/zap v1.21.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.opentelemetry.io/otel v1.28.0 // indirect
+	go.uber.org/atomic v1.9.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
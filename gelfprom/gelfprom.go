@@ -0,0 +1,219 @@
+// Package gelfprom exposes GELF core delivery metrics as prometheus.Collector.
+package gelfprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	gelf "github.com/snovichkov/zap-gelf"
+)
+
+type (
+	// Option interface.
+	Option interface {
+		apply(conf *optionConf)
+	}
+
+	// optionConf collector options.
+	optionConf struct {
+		namespace       string
+		constLabels     prometheus.Labels
+		sizeBuckets     []float64
+		durationBuckets []float64
+	}
+
+	// optionFunc wraps a func so it satisfies the Option interface.
+	optionFunc func(conf *optionConf)
+
+	// Collector collects delivery metrics of GELF cores.
+	//
+	// Collector implements both gelf.Observer and prometheus.Collector:
+	//
+	//	var collector = gelfprom.NewCollector()
+	//	prometheus.MustRegister(collector)
+	//	core, err := gelf.NewCore(gelf.Observers(collector))
+	Collector struct {
+		entries      *prometheus.CounterVec
+		entryErrors  *prometheus.CounterVec
+		messages     *prometheus.CounterVec
+		chunks       *prometheus.CounterVec
+		dropped      *prometheus.CounterVec
+		writeErrors  *prometheus.CounterVec
+		reconnects   *prometheus.CounterVec
+		payloadSize  *prometheus.HistogramVec
+		sendDuration *prometheus.HistogramVec
+	}
+)
+
+var (
+	// Ensure *Collector implements gelf.Observer.
+	_ gelf.Observer = (*Collector)(nil)
+
+	// Ensure *Collector implements prometheus.Collector.
+	_ prometheus.Collector = (*Collector)(nil)
+)
+
+// NewCollector collector constructor.
+func NewCollector(options ...Option) *Collector {
+	var conf = optionConf{
+		namespace:       "gelf",
+		sizeBuckets:     prometheus.ExponentialBuckets(128, 2, 10),
+		durationBuckets: prometheus.ExponentialBuckets(0.00005, 2, 12),
+	}
+
+	for _, option := range options {
+		option.apply(&conf)
+	}
+
+	return &Collector{
+		entries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   conf.namespace,
+			Name:        "entries_total",
+			Help:        "Total number of written log entries.",
+			ConstLabels: conf.constLabels,
+		}, []string{"level", "transport"}),
+		entryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   conf.namespace,
+			Name:        "entry_errors_total",
+			Help:        "Total number of log entries failed to write.",
+			ConstLabels: conf.constLabels,
+		}, []string{"level", "transport"}),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   conf.namespace,
+			Name:        "messages_total",
+			Help:        "Total number of sent GELF messages.",
+			ConstLabels: conf.constLabels,
+		}, []string{"transport"}),
+		chunks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   conf.namespace,
+			Name:        "chunks_total",
+			Help:        "Total number of sent GELF chunks.",
+			ConstLabels: conf.constLabels,
+		}, []string{"transport"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   conf.namespace,
+			Name:        "dropped_messages_total",
+			Help:        "Total number of GELF messages dropped without sending.",
+			ConstLabels: conf.constLabels,
+		}, []string{"transport"}),
+		writeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   conf.namespace,
+			Name:        "write_errors_total",
+			Help:        "Total number of GELF messages failed to send.",
+			ConstLabels: conf.constLabels,
+		}, []string{"transport"}),
+		reconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   conf.namespace,
+			Name:        "reconnects_total",
+			Help:        "Total number of TCP and TLS reconnects.",
+			ConstLabels: conf.constLabels,
+		}, []string{"transport"}),
+		payloadSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   conf.namespace,
+			Name:        "payload_size_bytes",
+			Help:        "Size of GELF message payload in bytes.",
+			ConstLabels: conf.constLabels,
+			Buckets:     conf.sizeBuckets,
+		}, []string{"transport", "stage"}),
+		sendDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   conf.namespace,
+			Name:        "send_duration_seconds",
+			Help:        "Duration of GELF message sending in seconds.",
+			ConstLabels: conf.constLabels,
+			Buckets:     conf.durationBuckets,
+		}, []string{"transport"}),
+	}
+}
+
+// Namespace set metrics namespace, "gelf" by default.
+func Namespace(value string) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.namespace = value
+	})
+}
+
+// ConstLabels set labels added to all metrics.
+func ConstLabels(value prometheus.Labels) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.constLabels = value
+	})
+}
+
+// SizeBuckets set payload size histogram buckets.
+func SizeBuckets(value []float64) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.sizeBuckets = value
+	})
+}
+
+// DurationBuckets set send duration histogram buckets.
+func DurationBuckets(value []float64) Option {
+	return optionFunc(func(conf *optionConf) {
+		conf.durationBuckets = value
+	})
+}
+
+// ObserveEntry implementation of gelf.Observer.
+func (c *Collector) ObserveEntry(event gelf.EntryEvent) {
+	var level = event.Level.String()
+	if event.Err != nil {
+		c.entryErrors.WithLabelValues(level, event.Transport).Inc()
+		return
+	}
+
+	c.entries.WithLabelValues(level, event.Transport).Inc()
+}
+
+// ObserveMessage implementation of gelf.Observer.
+func (c *Collector) ObserveMessage(event gelf.MessageEvent) {
+	c.payloadSize.WithLabelValues(event.Transport, "raw").Observe(float64(event.Size))
+	c.payloadSize.WithLabelValues(event.Transport, "compressed").Observe(float64(event.CompressedSize))
+
+	if event.Reconnects > 0 {
+		c.reconnects.WithLabelValues(event.Transport).Add(float64(event.Reconnects))
+	}
+
+	switch {
+	case event.Dropped:
+		c.dropped.WithLabelValues(event.Transport).Inc()
+	case event.Err != nil:
+		c.writeErrors.WithLabelValues(event.Transport).Inc()
+	default:
+		c.messages.WithLabelValues(event.Transport).Inc()
+		c.chunks.WithLabelValues(event.Transport).Add(float64(event.Chunks))
+		c.sendDuration.WithLabelValues(event.Transport).Observe(event.Latency.Seconds())
+	}
+}
+
+// Describe implementation of prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implementation of prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// apply implements Option.
+func (f optionFunc) apply(conf *optionConf) {
+	f(conf)
+}
+
+// collectors returns all collector metrics.
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.entries,
+		c.entryErrors,
+		c.messages,
+		c.chunks,
+		c.dropped,
+		c.writeErrors,
+		c.reconnects,
+		c.payloadSize,
+		c.sendDuration,
+	}
+}
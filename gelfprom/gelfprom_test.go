@@ -0,0 +1,76 @@
+package gelfprom_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelfprom"
+)
+
+func TestCollector(t *testing.T) {
+	var conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err, "Unexpected error")
+	defer conn.Close()
+
+	var (
+		core      zapcore.Core
+		collector = gelfprom.NewCollector(gelfprom.Namespace("test"))
+	)
+
+	core, err = gelf.NewCore(
+		gelf.Addr(conn.LocalAddr().String()),
+		gelf.ChunkSize(gelf.MinChunkSize),
+		gelf.CompressionType(gelf.CompressionNone),
+		gelf.Observers(collector),
+		gelf.OnError(func(error, zapcore.Entry) {}),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	logger.Info("info")
+	logger.Warn(strings.Repeat("x", gelf.MinChunkSize*2))
+	logger.Error(strings.Repeat("x", gelf.MinChunkSize*gelf.MaxChunkCount))
+
+	var registry = prometheus.NewPedanticRegistry()
+	assert.Nil(t, registry.Register(collector), "Unexpected error")
+
+	var expected = `
+# HELP test_entries_total Total number of written log entries.
+# TYPE test_entries_total counter
+test_entries_total{level="info",transport="udp"} 1
+test_entries_total{level="warn",transport="udp"} 1
+# HELP test_entry_errors_total Total number of log entries failed to write.
+# TYPE test_entry_errors_total counter
+test_entry_errors_total{level="error",transport="udp"} 1
+# HELP test_messages_total Total number of sent GELF messages.
+# TYPE test_messages_total counter
+test_messages_total{transport="udp"} 2
+# HELP test_chunks_total Total number of sent GELF chunks.
+# TYPE test_chunks_total counter
+test_chunks_total{transport="udp"} 4
+# HELP test_dropped_messages_total Total number of GELF messages dropped without sending.
+# TYPE test_dropped_messages_total counter
+test_dropped_messages_total{transport="udp"} 1
+`
+
+	assert.Nil(t, testutil.GatherAndCompare(
+		registry,
+		strings.NewReader(expected),
+		"test_entries_total",
+		"test_entry_errors_total",
+		"test_messages_total",
+		"test_chunks_total",
+		"test_dropped_messages_total",
+	))
+
+	assert.Equal(t, 2, testutil.CollectAndCount(collector, "test_payload_size_bytes"))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "test_send_duration_seconds"))
+}
@@ -0,0 +1,29 @@
+module github.com/snovichkov/zap-gelf/gelfprom
+
+go 1.20
+
+replace github.com/snovichkov/zap-gelf => ../
+
+require (
+	github.com/prometheus/client_golang v1.20.5
+	github.com/snovichkov/zap-gelf v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.9.0
+	go.uber.org/zap v1.21.0
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	go.uber.org/atomic v1.9.0 // indirect
+	go.uber.org/multierr v1.8.0 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
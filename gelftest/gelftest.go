@@ -0,0 +1,316 @@
+// Package gelftest provides in-process GELF server for tests.
+//
+// Server receives GELF messages over UDP, TCP and HTTP, decompresses gzip and zlib payloads, reassembles chunked
+// messages and keeps decoded messages in memory:
+//
+//	var server = gelftest.NewServer()
+//	defer server.Close()
+//
+//	core, err := gelf.NewCore(gelf.Addr(server.UDPAddr()))
+//	...
+//	messages, err := server.Wait(1, time.Second)
+package gelftest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/snovichkov/zap-gelf/gelfdecode"
+)
+
+const (
+	// maxDatagramSize maximal UDP datagram size.
+	maxDatagramSize = 65536
+)
+
+type (
+	// Message decoded GELF message.
+	Message = gelfdecode.Message
+
+	// Server in-process GELF server.
+	Server struct {
+		udp     net.PacketConn
+		tcp     net.Listener
+		http    net.Listener
+		server  *http.Server
+		decoder *gelfdecode.Decoder
+		wg      sync.WaitGroup
+
+		mu       sync.Mutex
+		conns    map[net.Conn]struct{}
+		messages []Message
+		errs     []error
+		notify   chan struct{}
+	}
+)
+
+var (
+	// ErrTimeout triggered when wait timeout exceeded.
+	ErrTimeout = errors.New("gelftest: wait timeout")
+)
+
+// NewServer starts UDP, TCP and HTTP GELF inputs on random local ports, it panics on listen errors.
+func NewServer() *Server {
+	var (
+		err error
+		s   = &Server{
+			conns:  make(map[net.Conn]struct{}),
+			notify: make(chan struct{}),
+		}
+	)
+
+	if s.decoder, err = gelfdecode.NewDecoder(); err != nil {
+		panic(fmt.Sprintf("gelftest: failed to create decoder: %v", err))
+	}
+
+	if s.udp, err = net.ListenPacket("udp", "127.0.0.1:0"); err != nil {
+		panic(fmt.Sprintf("gelftest: failed to listen on udp: %v", err))
+	}
+
+	if s.tcp, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		panic(fmt.Sprintf("gelftest: failed to listen on tcp: %v", err))
+	}
+
+	if s.http, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		panic(fmt.Sprintf("gelftest: failed to listen on http: %v", err))
+	}
+
+	s.server = &http.Server{Handler: http.HandlerFunc(s.serveHTTP)}
+
+	s.wg.Add(3)
+	go s.serveUDP()
+	go s.serveTCP()
+	go func() {
+		defer s.wg.Done()
+		_ = s.server.Serve(s.http)
+	}()
+
+	return s
+}
+
+// UDPAddr returns UDP input address.
+func (s *Server) UDPAddr() string {
+	return s.udp.LocalAddr().String()
+}
+
+// TCPAddr returns TCP input address.
+func (s *Server) TCPAddr() string {
+	return s.tcp.Addr().String()
+}
+
+// HTTPURL returns HTTP input URL.
+func (s *Server) HTTPURL() string {
+	return "http://" + s.http.Addr().String() + "/gelf"
+}
+
+// Messages returns received messages.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+// Errors returns errors of decoding received data.
+func (s *Server) Errors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]error(nil), s.errs...)
+}
+
+// Wait waits until at least n messages received and returns received messages.
+func (s *Server) Wait(n int, timeout time.Duration) ([]Message, error) {
+	var timer = time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		var (
+			messages = append([]Message(nil), s.messages...)
+			notify   = s.notify
+		)
+		s.mu.Unlock()
+
+		if len(messages) >= n {
+			return messages, nil
+		}
+
+		select {
+		case <-notify:
+		case <-timer.C:
+			return messages, ErrTimeout
+		}
+	}
+}
+
+// Reset forgets received messages and errors.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = nil
+	s.errs = nil
+}
+
+// Close stops server.
+func (s *Server) Close() error {
+	var err = s.udp.Close()
+	if cErr := s.tcp.Close(); err == nil {
+		err = cErr
+	}
+
+	if cErr := s.server.Close(); err == nil {
+		err = cErr
+	}
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
+
+// serveUDP receives UDP datagrams.
+func (s *Server) serveUDP() {
+	defer s.wg.Done()
+
+	var buf = make([]byte, maxDatagramSize)
+	for {
+		var n, _, err = s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		var message Message
+		if message, err = s.decoder.Decode(buf[:n]); err != nil {
+			s.fail(fmt.Errorf("gelftest: invalid datagram: %v", err))
+			continue
+		}
+
+		if message != nil {
+			s.receive(message)
+		}
+	}
+}
+
+// serveTCP accepts TCP connections.
+func (s *Server) serveTCP() {
+	defer s.wg.Done()
+
+	for {
+		var conn, err = s.tcp.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn receives null byte delimited messages from TCP connection.
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+
+		conn.Close()
+	}()
+
+	var reader = bufio.NewReader(conn)
+	for {
+		var frame, err = reader.ReadBytes(0)
+		if len(frame) > 1 || (len(frame) == 1 && frame[0] != 0) {
+			s.payload(bytes.TrimSuffix(frame, []byte{0}))
+		}
+
+		if err != nil {
+			if err != io.EOF && !isClosed(err) {
+				s.fail(err)
+			}
+
+			return
+		}
+	}
+}
+
+// serveHTTP receives messages posted to HTTP input.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/gelf" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body, err = ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.fail(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !s.payload(body) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// payload decode message payload, returns false on errors.
+func (s *Server) payload(data []byte) bool {
+	var message, err = gelfdecode.DecodePayload(data)
+	if err != nil {
+		s.fail(fmt.Errorf("gelftest: invalid message: %v", err))
+		return false
+	}
+
+	s.receive(message)
+
+	return true
+}
+
+// receive keeps received message.
+func (s *Server) receive(message Message) {
+	s.mu.Lock()
+	s.messages = append(s.messages, message)
+	close(s.notify)
+	s.notify = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// fail keeps decoding error.
+func (s *Server) fail(err error) {
+	s.mu.Lock()
+	s.errs = append(s.errs, err)
+	s.mu.Unlock()
+}
+
+// isClosed returns true when err caused by closed connection.
+func isClosed(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
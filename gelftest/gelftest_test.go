@@ -0,0 +1,130 @@
+package gelftest_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestServerUDP(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.ChunkSize(gelf.MinChunkSize),
+		gelf.CompressionType(gelf.CompressionNone),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	logger.Info("short")
+	logger.Info(strings.Repeat("x", gelf.MinChunkSize*3))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "short", messages[0]["short_message"])
+	assert.Equal(t, strings.Repeat("x", gelf.MinChunkSize*3), messages[1]["short_message"])
+	assert.Empty(t, server.Errors())
+}
+
+func TestServerCompression(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	for _, compressionType := range []int{gelf.CompressionGzip, gelf.CompressionZlib} {
+		var core, err = gelf.NewCore(
+			gelf.Addr(server.UDPAddr()),
+			gelf.CompressionType(compressionType),
+		)
+		assert.Nil(t, err, "Unexpected error")
+
+		zap.New(core).Info("compressed", zap.Int("type", compressionType))
+	}
+
+	var messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Len(t, messages, 2)
+
+	for _, message := range messages {
+		assert.Equal(t, "compressed", message["short_message"])
+	}
+}
+
+func TestServerTCP(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var conn, err = net.Dial("tcp", server.TCPAddr())
+	assert.Nil(t, err, "Unexpected error")
+
+	_, err = conn.Write([]byte(`{"short_message":"first"}` + "\x00" + `{"short_message":"second"}` + "\x00"))
+	assert.Nil(t, err, "Unexpected error")
+	assert.Nil(t, conn.Close(), "Unexpected error")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "first", messages[0]["short_message"])
+	assert.Equal(t, "second", messages[1]["short_message"])
+}
+
+func TestServerHTTP(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var (
+		body bytes.Buffer
+		gw   = gzip.NewWriter(&body)
+	)
+
+	_, _ = gw.Write([]byte(`{"short_message":"http"}`))
+	assert.Nil(t, gw.Close(), "Unexpected error")
+
+	var resp, err = http.Post(server.HTTPURL(), "application/json", &body)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resp.Body.Close()
+
+	resp, err = http.Post(server.HTTPURL(), "application/json", strings.NewReader("invalid"))
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "http", messages[0]["short_message"])
+	assert.Len(t, server.Errors(), 1)
+}
+
+func TestServerWaitTimeout(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(gelf.Addr(server.UDPAddr()))
+	assert.Nil(t, err, "Unexpected error")
+
+	zap.New(core).Check(zapcore.InfoLevel, "only").Write()
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, 100*time.Millisecond)
+	assert.Equal(t, gelftest.ErrTimeout, err)
+	assert.Len(t, messages, 1)
+
+	server.Reset()
+	assert.Empty(t, server.Messages())
+}
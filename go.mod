@@ -4,7 +4,7 @@ go 1.12
 
 require (
 	github.com/stretchr/testify v1.7.0
-	go.uber.org/atomic v1.9.0 // indirect
-	go.uber.org/multierr v1.8.0 // indirect
+	go.uber.org/atomic v1.9.0
+	go.uber.org/multierr v1.8.0
 	go.uber.org/zap v1.21.0
 )
@@ -0,0 +1,101 @@
+package gelf
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"os"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+type (
+	// MessageIDGenerator generates IDs of chunked messages, IDs should be unique across all processes sending
+	// messages to the same input. It is used concurrently.
+	MessageIDGenerator interface {
+		// NextID returns ID of the next chunked message.
+		NextID() uint64
+	}
+
+	// MessageIDGeneratorFunc wraps a func so it satisfies the MessageIDGenerator interface.
+	MessageIDGeneratorFunc func() uint64
+
+	// messageIDGenerator default message IDs generator.
+	messageIDGenerator struct {
+		base    uint64
+		counter atomic.Uint64
+	}
+)
+
+var (
+	// ErrNilMessageIDGenerator triggered when passed nil message IDs generator.
+	ErrNilMessageIDGenerator = errors.New("nil message id generator")
+)
+
+// MessageIDs set generator of chunked message IDs, by default NewMessageIDGenerator is used.
+func MessageIDs(value MessageIDGenerator) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if value == nil {
+			return ErrNilMessageIDGenerator
+		}
+
+		conf.messageIDs = value
+
+		return nil
+	})
+}
+
+// NewMessageIDGenerator create fast generator of message IDs, which does not read crypto/rand per message.
+//
+// Per process base combines random seed, start timestamp and host name hash, IDs are counter values added to base and
+// mixed by bijective splitmix64 finalizer, so IDs of one generator are unique until 2^64 messages are sent and IDs of
+// different processes collide with probability of random 64 bit values.
+func NewMessageIDGenerator() MessageIDGenerator {
+	var (
+		seed [8]byte
+		host string
+	)
+
+	if _, err := rand.Read(seed[:]); err != nil {
+		// fallback to process ID when crypto/rand is unavailable, start timestamp is mixed in below
+		binary.BigEndian.PutUint64(seed[:], uint64(os.Getpid())<<32)
+	}
+
+	host, _ = os.Hostname()
+
+	return &messageIDGenerator{
+		base: binary.BigEndian.Uint64(seed[:]) ^ uint64(time.Now().UnixNano()) ^ hostHash(host),
+	}
+}
+
+// NextID implementation of MessageIDGenerator.
+func (f MessageIDGeneratorFunc) NextID() uint64 {
+	return f()
+}
+
+// NextID implementation of MessageIDGenerator.
+func (g *messageIDGenerator) NextID() uint64 {
+	// See https://prng.di.unimi.it/splitmix64.c.
+	var z = g.base + g.counter.Inc()*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return z ^ (z >> 31)
+}
+
+// hostHash returns FNV-64a hash of host name.
+func hostHash(host string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	var hash = uint64(offset64)
+	for i := 0; i < len(host); i++ {
+		hash ^= uint64(host[i])
+		hash *= prime64
+	}
+
+	return hash
+}
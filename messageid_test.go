@@ -0,0 +1,140 @@
+package gelf_test
+
+import (
+	"crypto/rand"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestMessageIDs(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var (
+		calls atomic.Uint64
+		ids   = gelf.MessageIDGeneratorFunc(func() uint64 {
+			return calls.Inc()
+		})
+	)
+
+	var w, err = gelf.NewWriter(
+		gelf.Addr(server.UDPAddr()),
+		gelf.ChunkSize(gelf.MinChunkSize),
+		gelf.CompressionType(gelf.CompressionNone),
+		gelf.MessageIDs(ids),
+	)
+	assert.Nil(t, err, "Unexpected error")
+	defer w.Close()
+
+	var payload = `{"version":"1.1","host":"example.org","short_message":"` + strings.Repeat("x", gelf.MinChunkSize*2) + `"}`
+	for i := 0; i < 2; i++ {
+		_, err = io.WriteString(w, payload)
+		assert.Nil(t, err, "Unexpected error")
+	}
+
+	_, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, uint64(2), calls.Load())
+
+	w, err = gelf.NewWriter(gelf.MessageIDs(nil))
+	assert.Equal(t, gelf.ErrNilMessageIDGenerator, err)
+	assert.Nil(t, w, "Expected nil")
+}
+
+func TestMessageIDGeneratorUnique(t *testing.T) {
+	const (
+		goroutines = 8
+		perRoutine = 10000
+	)
+
+	var (
+		generators = []gelf.MessageIDGenerator{gelf.NewMessageIDGenerator(), gelf.NewMessageIDGenerator()}
+		ids        = make(map[uint64]struct{}, goroutines*perRoutine)
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+	)
+
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(generator gelf.MessageIDGenerator) {
+			defer wg.Done()
+
+			var local = make([]uint64, 0, perRoutine)
+			for j := 0; j < perRoutine; j++ {
+				local = append(local, generator.NextID())
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, id := range local {
+				ids[id] = struct{}{}
+			}
+		}(generators[i%len(generators)])
+	}
+
+	wg.Wait()
+	assert.Len(t, ids, goroutines*perRoutine, "Expected unique IDs")
+}
+
+func BenchmarkMessageIDGenerator(b *testing.B) {
+	var generator = gelf.NewMessageIDGenerator()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = generator.NextID()
+		}
+	})
+}
+
+func BenchmarkCryptoRandMessageID(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var id [8]byte
+		for pb.Next() {
+			_, _ = rand.Read(id[:])
+		}
+	})
+}
+
+func BenchmarkWriteChunked(b *testing.B) {
+	// nobody reads datagrams, so only writer is measured
+	var conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	var w io.WriteCloser
+	if w, err = gelf.NewWriter(
+		gelf.Addr(conn.LocalAddr().String()),
+		gelf.ChunkSize(gelf.MinChunkSize),
+		gelf.CompressionType(gelf.CompressionNone),
+	); err != nil {
+		b.Fatal(err)
+	}
+
+	defer w.Close()
+
+	var payload = []byte(`{"version":"1.1","host":"example.org","short_message":"` + strings.Repeat("x", 4096) + `"}`)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(payload)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = w.Write(payload)
+	}
+}
@@ -0,0 +1,82 @@
+package gelf
+
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type (
+	// Observer receives delivery events of the core, see Observers.
+	//
+	// Methods must be safe for concurrent use. ObserveEntry is called synchronously on the logging goroutine,
+	// ObserveMessage is called on the logging goroutine too, or on the sending goroutine when CompressWorkers is set.
+	Observer interface {
+		// ObserveEntry called after each entry written by the core.
+		ObserveEntry(event EntryEvent)
+
+		// ObserveMessage called after each GELF message sent by the writer.
+		ObserveMessage(event MessageEvent)
+	}
+
+	// EntryEvent describes written entry.
+	EntryEvent struct {
+		// Level entry level.
+		Level zapcore.Level
+
+		// Transport GELF transport, e.g. TransportUDP.
+		Transport string
+
+		// Err entry write error.
+		Err error
+	}
+
+	// MessageEvent describes sent GELF message.
+	MessageEvent struct {
+		// Transport GELF transport, e.g. TransportUDP.
+		Transport string
+
+		// Size message size before compression.
+		Size int
+
+		// CompressedSize message size after compression.
+		CompressedSize int
+
+		// Chunks count of chunks needed to send message, 1 for not chunked message.
+		Chunks int
+
+		// Latency message send duration.
+		Latency time.Duration
+
+		// Reconnects count of reconnects made while sending message.
+		Reconnects int
+
+		// Dropped true when message was dropped without sending.
+		Dropped bool
+
+		// Err message send error.
+		Err error
+	}
+)
+
+// Observers set delivery events observers.
+func Observers(value ...Observer) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.observers = append(conf.observers, value...)
+		return nil
+	})
+}
+
+// observeEntry notify observers about written entry.
+func (w *writer) observeEntry(event EntryEvent) {
+	for _, observer := range w.observers {
+		observer.ObserveEntry(event)
+	}
+}
+
+// observeMessage notify observers about sent message.
+func (w *writer) observeMessage(event MessageEvent) {
+	for _, observer := range w.observers {
+		observer.ObserveMessage(event)
+	}
+}
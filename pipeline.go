@@ -0,0 +1,189 @@
+package gelf
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	// pipelineQueueSize count of queued messages per compression worker.
+	pipelineQueueSize = 64
+)
+
+type (
+	// pipeline compresses messages by concurrent workers and sends them in order of writes.
+	pipeline struct {
+		writer  *writer
+		jobs    chan *compressJob
+		ordered chan *compressJob
+		submit  sync.Mutex
+		closed  bool
+		done    chan struct{}
+
+		// mu guards counts of written and sent messages and delivery error not reported yet, sent is broadcast on
+		// each sent message.
+		mu      sync.Mutex
+		sent    *sync.Cond
+		written uint64
+		flushed uint64
+		err     error
+	}
+
+	// compressJob message compressed by pipeline.
+	compressJob struct {
+		buf    []byte
+		cBytes []byte
+		err    error
+		done   chan struct{}
+	}
+)
+
+var (
+	// ErrInvalidCompressWorkers triggered when passed invalid count of compression workers.
+	ErrInvalidCompressWorkers = errors.New("invalid compress workers")
+
+	// ErrWriterClosed triggered when message is written to closed writer.
+	ErrWriterClosed = errors.New("writer closed")
+)
+
+// CompressWorkers set count of workers compressing messages concurrently.
+//
+// Write copies message to queue and returns before message is sent, messages are sent in order of writes by one
+// goroutine, so chunks of each message are contiguous. Sync waits until queued messages are sent. Delivery errors are
+// reported to Observers and Stats, the first error since the last report is returned by the next Write, Sync or Close.
+// Error returned by Write reaches OnError handler with entry of the next message, see OnError.
+func CompressWorkers(value int) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if value <= 0 {
+			return ErrInvalidCompressWorkers
+		}
+
+		conf.compressWorkers = value
+
+		return nil
+	})
+}
+
+// newPipeline create pipeline and start its goroutines.
+func newPipeline(w *writer, workers int) *pipeline {
+	var p = &pipeline{
+		writer:  w,
+		jobs:    make(chan *compressJob, workers*pipelineQueueSize),
+		ordered: make(chan *compressJob, workers*pipelineQueueSize),
+		done:    make(chan struct{}),
+	}
+
+	p.sent = sync.NewCond(&p.mu)
+
+	for i := 0; i < workers; i++ {
+		go p.compress()
+	}
+
+	go p.send()
+
+	return p
+}
+
+// write queues copy of buf, returns delivery error of previously queued message.
+func (p *pipeline) write(buf []byte) (int, error) {
+	var job = &compressJob{
+		buf:  append([]byte(nil), buf...),
+		done: make(chan struct{}),
+	}
+
+	p.submit.Lock()
+	defer p.submit.Unlock()
+
+	if p.closed {
+		return 0, ErrWriterClosed
+	}
+
+	// jobs are queued to both channels under lock, so sender waits for them in order of writes
+	p.mu.Lock()
+	p.written++
+	var err = p.takeErr()
+	p.mu.Unlock()
+
+	p.writer.stats.queueDepth.Inc()
+	p.ordered <- job
+	p.jobs <- job
+
+	return len(buf), err
+}
+
+// compress compresses queued messages.
+func (p *pipeline) compress() {
+	for job := range p.jobs {
+		job.cBytes, job.err = p.writer.compress(job.buf)
+		close(job.done)
+	}
+}
+
+// send sends compressed messages in order of writes.
+func (p *pipeline) send() {
+	defer close(p.done)
+
+	for job := range p.ordered {
+		<-job.done
+
+		var err = job.err
+		if err != nil {
+			p.writer.observeMessage(MessageEvent{
+				Transport: p.writer.transport,
+				Size:      len(job.buf),
+				Err:       err,
+			})
+		} else {
+			_, err = p.writer.send(len(job.buf), job.cBytes)
+		}
+
+		p.writer.stats.queueDepth.Dec()
+
+		p.mu.Lock()
+		p.flushed++
+		if p.err == nil {
+			p.err = err
+		}
+
+		p.sent.Broadcast()
+		p.mu.Unlock()
+	}
+}
+
+// sync waits until messages queued before the call are sent, returns delivery error not reported yet.
+func (p *pipeline) sync() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for written := p.written; p.flushed < written; {
+		p.sent.Wait()
+	}
+
+	return p.takeErr()
+}
+
+// close stops accepting messages, waits until queued messages are sent and returns delivery error not reported yet.
+func (p *pipeline) close() error {
+	p.submit.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+		close(p.ordered)
+	}
+	p.submit.Unlock()
+
+	<-p.done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.takeErr()
+}
+
+// takeErr returns and resets delivery error not reported yet, mu must be held.
+func (p *pipeline) takeErr() error {
+	var err = p.err
+	p.err = nil
+
+	return err
+}
@@ -0,0 +1,67 @@
+package gelf
+
+import (
+	"errors"
+)
+
+const (
+	// ProtocolVersion10 GELF 1.0, facility, file and line are spec keys and full_message is always sent.
+	ProtocolVersion10 = "1.0"
+
+	// ProtocolVersion11 GELF 1.1, default.
+	ProtocolVersion11 = "1.1"
+)
+
+var (
+	// ErrUnknownProtocolVersion triggered when passed unknown GELF protocol version.
+	ErrUnknownProtocolVersion = errors.New("unknown protocol version")
+)
+
+// ProtocolVersion set GELF protocol version, sets version field and follows spec keys of the version.
+//
+// In GELF 1.0 mode facility, file and line fields are not prefixed by underscore, caller is sent as file and line
+// fields instead of _caller field and full_message carries the full message: message, error and stack trace rendered
+// by DefaultFullMessageTemplate unless FullMessageTemplate is set.
+func ProtocolVersion(value string) Option {
+	return optionFunc(func(conf *optionConf) error {
+		switch value {
+		case ProtocolVersion10, ProtocolVersion11:
+		default:
+			return ErrUnknownProtocolVersion
+		}
+
+		conf.protocol = value
+		conf.version = value
+
+		return nil
+	})
+}
+
+// legacy returns true in GELF 1.0 mode.
+func (conf *optionConf) legacy() bool {
+	return conf.protocol == ProtocolVersion10
+}
+
+// escapeProtocolKey append prefix to additional field keys, keys defined by protocol version are not prefixed.
+func escapeProtocolKey(protocol, value string) string {
+	switch value {
+	case "id":
+		return "__id"
+	case "version", "host", "short_message", "full_message", "timestamp", "level":
+		return value
+	case "facility", "file", "line":
+		if protocol == ProtocolVersion10 {
+			return value
+		}
+	}
+
+	if len(value) == 0 {
+		return "_"
+	}
+
+	if value[0] == '_' {
+		return value
+	}
+
+	return "_" + value
+}
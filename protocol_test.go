@@ -0,0 +1,78 @@
+package gelf_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestProtocolVersion(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(gelf.Addr(server.UDPAddr()), gelf.ProtocolVersion(gelf.ProtocolVersion10))
+	assert.Nil(t, err, "Unexpected error")
+	zap.New(core, zap.AddCaller()).Info("legacy", zap.String("facility", "billing"), zap.Int("id", 1))
+
+	core, err = gelf.NewCore(gelf.Addr(server.UDPAddr()), gelf.ProtocolVersion(gelf.ProtocolVersion11))
+	assert.Nil(t, err, "Unexpected error")
+	zap.New(core, zap.AddCaller()).Info("current", zap.String("facility", "billing"))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Equal(t, "1.0", message["version"])
+	assert.Equal(t, "billing", message["facility"])
+	assert.Regexp(t, `protocol_test\.go$`, message["file"])
+	assert.IsType(t, 0.0, message["line"])
+	assert.Equal(t, 1.0, message["__id"])
+	assert.NotContains(t, message, "_caller")
+	assert.NotContains(t, message, "_facility")
+
+	message = messages[1]
+	assert.Equal(t, "1.1", message["version"])
+	assert.Equal(t, "billing", message["_facility"])
+	assert.Regexp(t, `protocol_test\.go:\d+$`, message["_caller"])
+	assert.NotContains(t, message, "file")
+
+	server.Reset()
+
+	var buf bytes.Buffer
+	core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.ProtocolVersion(gelf.ProtocolVersion10),
+		gelf.Facility("app"),
+		gelf.WriteSyncers(zapcore.AddSync(&buf)),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core, zap.AddStacktrace(zap.ErrorLevel)).With(zap.String("facility", "with"))
+	logger.Error("failed", zap.String("_facility", "field"), zap.Error(errors.New("timeout")))
+	logger.Info("done")
+
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	assert.Equal(t, 1, strings.Count(strings.SplitN(buf.String(), "\n", 2)[0], `facility"`), "Expected one facility key")
+
+	message = messages[0]
+	assert.Equal(t, "app", message["facility"])
+	assert.NotContains(t, message, "_facility")
+	assert.Regexp(t, `^failed\n\ntimeout\n\ngithub.com/snovichkov/zap-gelf_test\.TestProtocolVersion\n`, message["full_message"])
+	assert.Equal(t, "done", messages[1]["full_message"])
+
+	core, err = gelf.NewCore(gelf.ProtocolVersion("2.0"))
+	assert.Equal(t, gelf.ErrUnknownProtocolVersion, err)
+	assert.Nil(t, core, "Expected nil")
+}
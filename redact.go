@@ -0,0 +1,295 @@
+package gelf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// RedactMask replace redacted value by RedactedValue.
+	RedactMask = 0
+
+	// RedactHash replace redacted value by hex encoded HMAC-SHA256 of value with salt set by RedactSalt.
+	RedactHash = 1
+
+	// RedactDrop drop field with redacted value, matched parts of messages are removed.
+	RedactDrop = 2
+
+	// RedactedValue replaces masked values.
+	RedactedValue = "[REDACTED]"
+)
+
+type (
+	// redactKeyRule redacts values of fields with matched keys.
+	redactKeyRule struct {
+		patterns []string
+		strategy int
+	}
+
+	// redactValueRule redacts matched parts of string values and messages.
+	redactValueRule struct {
+		patterns []*regexp.Regexp
+		strategy int
+	}
+
+	// redactor redacts fields and messages before encoding.
+	redactor struct {
+		keys   []redactKeyRule
+		values []redactValueRule
+		salt   []byte
+	}
+)
+
+var (
+	// ErrUnknownRedactStrategy triggered when passed invalid redact strategy.
+	ErrUnknownRedactStrategy = errors.New("unknown redact strategy")
+
+	// ErrInvalidRedactPattern triggered when passed invalid key pattern.
+	ErrInvalidRedactPattern = errors.New("invalid redact pattern")
+
+	// CreditCardRegexp matches credit card numbers optionally separated by spaces or dashes.
+	CreditCardRegexp = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+	// EmailRegexp matches email addresses.
+	EmailRegexp = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+
+	// JWTRegexp matches JSON web tokens.
+	JWTRegexp = regexp.MustCompile(`\beyJ[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]+\.[a-zA-Z0-9_\-]*`)
+)
+
+// RedactKeys set redaction of fields which keys match glob patterns, e.g. password or *_token.
+//
+// Patterns are case insensitive and matched against keys without underscore prefix, values of any type are redacted.
+// Rules are applied to fields passed to With and Write in order of options, first matched rule is used.
+func RedactKeys(strategy int, patterns ...string) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if err := checkRedactStrategy(strategy); err != nil {
+			return err
+		}
+
+		var rule = redactKeyRule{strategy: strategy}
+		for _, pattern := range patterns {
+			pattern = strings.ToLower(pattern)
+			if _, err := path.Match(pattern, ""); err != nil {
+				return ErrInvalidRedactPattern
+			}
+
+			rule.patterns = append(rule.patterns, pattern)
+		}
+
+		conf.redactKeys = append(conf.redactKeys, rule)
+
+		return nil
+	})
+}
+
+// RedactValues set redaction of parts of string and error field values, messages and stacktraces matched by regular
+// expressions, e.g. CreditCardRegexp, EmailRegexp or JWTRegexp.
+//
+// Byte string, stringer and reflected string values are redacted as strings. Arrays, objects and other reflected values
+// are redacted as a whole when any part of their encoded value is matched.
+func RedactValues(strategy int, patterns ...*regexp.Regexp) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if err := checkRedactStrategy(strategy); err != nil {
+			return err
+		}
+
+		conf.redactValues = append(conf.redactValues, redactValueRule{patterns: patterns, strategy: strategy})
+
+		return nil
+	})
+}
+
+// RedactSalt set salt of RedactHash strategy.
+func RedactSalt(value string) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.redactSalt = value
+		return nil
+	})
+}
+
+// checkRedactStrategy returns error when strategy is unknown.
+func checkRedactStrategy(strategy int) error {
+	switch strategy {
+	case RedactMask, RedactHash, RedactDrop:
+		return nil
+	}
+
+	return ErrUnknownRedactStrategy
+}
+
+// newRedactor create redactor, returns nil when no rules set.
+func newRedactor(conf *optionConf) *redactor {
+	if len(conf.redactKeys) == 0 && len(conf.redactValues) == 0 {
+		return nil
+	}
+
+	return &redactor{
+		keys:   conf.redactKeys,
+		values: conf.redactValues,
+		salt:   []byte(conf.redactSalt),
+	}
+}
+
+// field returns redacted field, false when field should be dropped.
+func (r *redactor) field(field zapcore.Field) (zapcore.Field, bool) {
+	if field.Type == zapcore.SkipType {
+		return field, true
+	}
+
+	var key = strings.ToLower(strings.TrimLeft(field.Key, "_"))
+	for _, rule := range r.keys {
+		for _, pattern := range rule.patterns {
+			if matched, _ := path.Match(pattern, key); !matched {
+				continue
+			}
+
+			switch rule.strategy {
+			case RedactDrop:
+				return field, false
+			case RedactHash:
+				return zap.String(field.Key, r.hash(fieldValue(field))), true
+			default:
+				return zap.String(field.Key, RedactedValue), true
+			}
+		}
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		return r.scalar(field, field.String)
+	case zapcore.ByteStringType, zapcore.StringerType:
+		return r.scalar(field, fieldValue(field))
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok {
+			return r.scalar(field, err.Error())
+		}
+	case zapcore.ReflectType:
+		if value, ok := field.Interface.(string); ok {
+			return r.scalar(field, value)
+		}
+
+		return r.composite(field)
+	case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType:
+		return r.composite(field)
+	}
+
+	return field, true
+}
+
+// scalar returns field with redacted matched parts of value rendered to string, false when field should be dropped.
+func (r *redactor) scalar(field zapcore.Field, value string) (zapcore.Field, bool) {
+	var redacted, ok = r.string(value)
+	if !ok {
+		return field, false
+	}
+
+	if redacted != value {
+		return zap.String(field.Key, redacted), true
+	}
+
+	return field, true
+}
+
+// composite returns field redacted as a whole when any part of its encoded value is matched, false when field should
+// be dropped.
+func (r *redactor) composite(field zapcore.Field) (zapcore.Field, bool) {
+	if len(r.values) == 0 {
+		return field, true
+	}
+
+	var value = fieldValue(field)
+	for _, rule := range r.values {
+		for _, pattern := range rule.patterns {
+			if !pattern.MatchString(value) {
+				continue
+			}
+
+			switch rule.strategy {
+			case RedactDrop:
+				return field, false
+			case RedactHash:
+				return zap.String(field.Key, r.hash(value)), true
+			default:
+				return zap.String(field.Key, RedactedValue), true
+			}
+		}
+	}
+
+	return field, true
+}
+
+// message returns message with redacted matched parts.
+func (r *redactor) message(value string) string {
+	for _, rule := range r.values {
+		for _, pattern := range rule.patterns {
+			value = pattern.ReplaceAllStringFunc(value, func(match string) string {
+				return r.replace(rule.strategy, match)
+			})
+		}
+	}
+
+	return value
+}
+
+// string returns value with redacted matched parts, false when value should be dropped.
+func (r *redactor) string(value string) (string, bool) {
+	for _, rule := range r.values {
+		for _, pattern := range rule.patterns {
+			if !pattern.MatchString(value) {
+				continue
+			}
+
+			if rule.strategy == RedactDrop {
+				return "", false
+			}
+
+			value = pattern.ReplaceAllStringFunc(value, func(match string) string {
+				return r.replace(rule.strategy, match)
+			})
+		}
+	}
+
+	return value, true
+}
+
+// replace returns replacement of matched value.
+func (r *redactor) replace(strategy int, value string) string {
+	switch strategy {
+	case RedactDrop:
+		return ""
+	case RedactHash:
+		return r.hash(value)
+	default:
+		return RedactedValue
+	}
+}
+
+// hash returns hex encoded HMAC-SHA256 of value.
+func (r *redactor) hash(value string) string {
+	var mac = hmac.New(sha256.New, r.salt)
+	_, _ = mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// fieldValue returns string representation of field value.
+func fieldValue(field zapcore.Field) string {
+	if field.Type == zapcore.StringType {
+		return field.String
+	}
+
+	var enc = zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+
+	return fmt.Sprint(enc.Fields[field.Key])
+}
@@ -0,0 +1,246 @@
+package gelf
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+type (
+	// Matcher selects entries sent to route, fields contain fields passed to With and Write.
+	Matcher func(entry zapcore.Entry, fields []zapcore.Field) bool
+
+	// RouterOption interface.
+	RouterOption interface {
+		applyRouter(conf *routerConf) error
+	}
+
+	// routerOptionFunc wraps a func so it satisfies the RouterOption interface.
+	routerOptionFunc func(conf *routerConf) error
+
+	// routerConf router options.
+	routerConf struct {
+		routes   []route
+		fallback *wrappedCore
+	}
+
+	// route destination of matched entries.
+	route struct {
+		match Matcher
+		core  *wrappedCore
+	}
+
+	// routerCore implements zapcore.Core, sends each entry to core of first matched route.
+	routerCore struct {
+		routes   []route
+		fallback *wrappedCore
+		fields   []zapcore.Field
+	}
+)
+
+var (
+	// ErrNoRoutes triggered when router has neither routes nor default route.
+	ErrNoRoutes = errors.New("no routes")
+
+	// ErrDuplicateDefaultRoute triggered when default route is set twice.
+	ErrDuplicateDefaultRoute = errors.New("duplicate default route")
+
+	// Ensure *routerCore implements zapcore.Core.
+	_ zapcore.Core = (*routerCore)(nil)
+
+	// Ensure *routerCore implements io.Closer.
+	_ io.Closer = (*routerCore)(nil)
+)
+
+// NewRouterCore routing zap core constructor.
+//
+// Each route has own core with own transport, compression and chunking options, entry is sent to the first matched
+// route or to the default route, entries matched by no route are dropped when default route is not set:
+//
+//	core, err := gelf.NewRouterCore(
+//		gelf.Route(gelf.MatchLevel(zapcore.ErrorLevel), gelf.Addr("graylog:12202")),
+//		gelf.Route(gelf.MatchLoggerName("audit"), gelf.Addr("graylog:12203"), gelf.Transport(gelf.TransportTCP)),
+//		gelf.DefaultRoute(gelf.Addr("graylog:12201")),
+//	)
+func NewRouterCore(options ...RouterOption) (_ zapcore.Core, err error) {
+	var conf routerConf
+	for _, option := range options {
+		if err = option.applyRouter(&conf); err != nil {
+			conf.close()
+			return nil, err
+		}
+	}
+
+	if len(conf.routes) == 0 && conf.fallback == nil {
+		return nil, ErrNoRoutes
+	}
+
+	return &routerCore{
+		routes:   conf.routes,
+		fallback: conf.fallback,
+	}, nil
+}
+
+// Route set route of entries selected by matcher to core created with options.
+func Route(match Matcher, options ...Option) RouterOption {
+	return routerOptionFunc(func(conf *routerConf) error {
+		var core, err = newCore(options)
+		if err != nil {
+			return err
+		}
+
+		conf.routes = append(conf.routes, route{match: match, core: core})
+
+		return nil
+	})
+}
+
+// DefaultRoute set route of entries matched by no route to core created with options.
+func DefaultRoute(options ...Option) RouterOption {
+	return routerOptionFunc(func(conf *routerConf) (err error) {
+		if conf.fallback != nil {
+			return ErrDuplicateDefaultRoute
+		}
+
+		conf.fallback, err = newCore(options)
+
+		return err
+	})
+}
+
+// MatchLevel matches entries with level greater than or equal to level.
+func MatchLevel(level zapcore.Level) Matcher {
+	return func(entry zapcore.Entry, _ []zapcore.Field) bool {
+		return entry.Level >= level
+	}
+}
+
+// MatchLoggerName matches entries of logger with name or its children, e.g. audit matches audit and audit.db.
+func MatchLoggerName(name string) Matcher {
+	return func(entry zapcore.Entry, _ []zapcore.Field) bool {
+		return entry.LoggerName == name || strings.HasPrefix(entry.LoggerName, name+".")
+	}
+}
+
+// MatchField matches entries with any field satisfying predicate.
+func MatchField(predicate func(field zapcore.Field) bool) Matcher {
+	return func(_ zapcore.Entry, fields []zapcore.Field) bool {
+		for _, field := range fields {
+			if predicate(field) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Enabled implementation of zapcore.Core.
+func (r *routerCore) Enabled(l zapcore.Level) bool {
+	for _, route := range r.routes {
+		if route.core.Enabled(l) {
+			return true
+		}
+	}
+
+	return r.fallback != nil && r.fallback.Enabled(l)
+}
+
+// With implementation of zapcore.Core.
+func (r *routerCore) With(fields []zapcore.Field) zapcore.Core {
+	var clone = &routerCore{
+		routes: make([]route, 0, len(r.routes)),
+		fields: make([]zapcore.Field, 0, len(r.fields)+len(fields)),
+	}
+
+	clone.fields = append(append(clone.fields, r.fields...), fields...)
+	for _, route := range r.routes {
+		route.core = route.core.With(fields).(*wrappedCore)
+		clone.routes = append(clone.routes, route)
+	}
+
+	if r.fallback != nil {
+		clone.fallback = r.fallback.With(fields).(*wrappedCore)
+	}
+
+	return clone
+}
+
+// Check implementation of zapcore.Core.
+func (r *routerCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if r.Enabled(e.Level) {
+		return ce.AddCore(e, r)
+	}
+
+	return ce
+}
+
+// Write implementation of zapcore.Core.
+func (r *routerCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	var core = r.route(e, fields)
+	if core == nil || !core.allow(e) {
+		return nil
+	}
+
+	return core.Write(e, fields)
+}
+
+// Sync implementation of zapcore.Core.
+func (r *routerCore) Sync() (err error) {
+	for _, route := range r.routes {
+		err = multierr.Append(err, route.core.Sync())
+	}
+
+	if r.fallback != nil {
+		err = multierr.Append(err, r.fallback.Sync())
+	}
+
+	return err
+}
+
+// Close implementation of io.Closer, closes cores of all routes.
+func (r *routerCore) Close() (err error) {
+	for _, route := range r.routes {
+		err = multierr.Append(err, route.core.Close())
+	}
+
+	if r.fallback != nil {
+		err = multierr.Append(err, r.fallback.Close())
+	}
+
+	return err
+}
+
+// applyRouter implements RouterOption.
+func (f routerOptionFunc) applyRouter(conf *routerConf) error {
+	return f(conf)
+}
+
+// route returns core of first matched route.
+func (r *routerCore) route(e zapcore.Entry, fields []zapcore.Field) *wrappedCore {
+	if len(r.fields) > 0 {
+		fields = append(r.fields[:len(r.fields):len(r.fields)], fields...)
+	}
+
+	for _, route := range r.routes {
+		if route.match(e, fields) {
+			return route.core
+		}
+	}
+
+	return r.fallback
+}
+
+// close closes writers of created routes.
+func (conf *routerConf) close() {
+	for _, route := range conf.routes {
+		_ = route.core.writer.Close()
+	}
+
+	if conf.fallback != nil {
+		_ = conf.fallback.writer.Close()
+	}
+}
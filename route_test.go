@@ -0,0 +1,79 @@
+package gelf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestRouterCore(t *testing.T) {
+	var errorServer, auditServer, defaultServer = gelftest.NewServer(), gelftest.NewServer(), gelftest.NewServer()
+	defer errorServer.Close()
+	defer auditServer.Close()
+	defer defaultServer.Close()
+
+	var core, err = gelf.NewRouterCore(
+		gelf.Route(gelf.MatchLevel(zapcore.ErrorLevel), gelf.Addr(errorServer.UDPAddr())),
+		gelf.Route(
+			gelf.MatchLoggerName("audit"),
+			gelf.Addr(auditServer.TCPAddr()),
+			gelf.Transport(gelf.TransportTCP),
+		),
+		gelf.Route(
+			gelf.MatchField(func(field zapcore.Field) bool {
+				return field.Key == "audit" && field.Type == zapcore.BoolType && field.Integer == 1
+			}),
+			gelf.Addr(auditServer.HTTPURL()),
+			gelf.Transport(gelf.TransportHTTP),
+		),
+		gelf.DefaultRoute(gelf.Addr(defaultServer.UDPAddr()), gelf.Level(zapcore.InfoLevel)),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	logger.Error("error")
+	logger.Named("audit.db").Info("audit by name")
+	logger.With(zap.Bool("audit", true)).Info("audit by field")
+	logger.Info("info")
+	logger.Debug("debug")
+
+	var messages []gelftest.Message
+	messages, err = errorServer.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "error", messages[0]["short_message"])
+
+	messages, err = auditServer.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.ElementsMatch(t, []interface{}{"audit by name", "audit by field"}, []interface{}{
+		messages[0]["short_message"], messages[1]["short_message"],
+	})
+
+	messages, err = defaultServer.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "info", messages[0]["short_message"])
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, defaultServer.Messages(), 1)
+	assert.Len(t, errorServer.Messages(), 1)
+	assert.Nil(t, core.Sync(), "Unexpected error")
+}
+
+func TestRouterCoreInvalid(t *testing.T) {
+	var core, err = gelf.NewRouterCore()
+	assert.Equal(t, gelf.ErrNoRoutes, err)
+	assert.Nil(t, core, "Expected nil")
+
+	core, err = gelf.NewRouterCore(gelf.DefaultRoute(), gelf.DefaultRoute())
+	assert.Equal(t, gelf.ErrDuplicateDefaultRoute, err)
+	assert.Nil(t, core, "Expected nil")
+
+	core, err = gelf.NewRouterCore(gelf.Route(gelf.MatchLevel(zapcore.ErrorLevel), gelf.ChunkSize(1)))
+	assert.Equal(t, gelf.ErrChunkTooSmall, err)
+	assert.Nil(t, core, "Expected nil")
+}
@@ -0,0 +1,344 @@
+package gelf
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// DefaultSummaryInterval is default interval of suppressed entries summary.
+	DefaultSummaryInterval = 10 * time.Second
+
+	// samplingCounters count of per level sampling counters.
+	samplingCounters = 4096
+
+	// summaryMessage suppressed entries summary message.
+	summaryMessage = "gelf: entries suppressed"
+)
+
+type (
+	// samplingConf per level sampling options.
+	samplingConf struct {
+		tick       time.Duration
+		first      uint64
+		thereafter uint64
+	}
+
+	// sampler drops entries by per level sampling and reports suppressed entries.
+	sampler struct {
+		write       func(e zapcore.Entry, fields []zapcore.Field) error
+		levels      map[zapcore.Level]*levelSampler
+		limiter     *rateLimiter
+		interval    time.Duration
+		nextSummary atomic.Int64
+		sampled     atomic.Uint64
+
+		// mu guards summary timer and closed flag, pending counts armed summary timers.
+		mu      sync.Mutex
+		timer   *time.Timer
+		closed  bool
+		pending sync.WaitGroup
+	}
+
+	// levelSampler samples entries of one level, see zapcore.NewSamplerWithOptions.
+	levelSampler struct {
+		samplingConf
+		counters [samplingCounters]samplingCounter
+	}
+
+	// samplingCounter count entries with same message per tick.
+	samplingCounter struct {
+		resetAt atomic.Int64
+		counter atomic.Uint64
+	}
+
+	// rateLimiter token bucket limiting bytes per second sent by writer.
+	rateLimiter struct {
+		mu      sync.Mutex
+		rate    float64
+		burst   float64
+		tokens  float64
+		last    time.Time
+		dropped atomic.Uint64
+		onDrop  func()
+	}
+)
+
+var (
+	// ErrInvalidSampling triggered when passed invalid sampling options.
+	ErrInvalidSampling = errors.New("invalid sampling")
+
+	// ErrInvalidRateLimit triggered when passed invalid rate limit.
+	ErrInvalidRateLimit = errors.New("invalid rate limit")
+
+	// ErrInvalidSummaryInterval triggered when passed invalid summary interval.
+	ErrInvalidSummaryInterval = errors.New("invalid summary interval")
+)
+
+// Sampling set sampling of entries with given level.
+//
+// Each tick first entries with the same level and message are logged, thereafter every thereafter-th entry is logged
+// and the rest are dropped. Zero thereafter drops all entries after first.
+func Sampling(level zapcore.Level, tick time.Duration, first, thereafter int) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if tick <= 0 || first < 0 || thereafter < 0 {
+			return ErrInvalidSampling
+		}
+
+		if conf.sampling == nil {
+			conf.sampling = make(map[zapcore.Level]samplingConf)
+		}
+
+		conf.sampling[level] = samplingConf{
+			tick:       tick,
+			first:      uint64(first),
+			thereafter: uint64(thereafter),
+		}
+
+		return nil
+	})
+}
+
+// RateLimit set limit of compressed bytes per second sent by the core, messages over limit are dropped.
+//
+// Burst is the maximal size of messages sent at once, it should not be less than the largest message size.
+func RateLimit(bytesPerSecond, burst int) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if bytesPerSecond <= 0 || burst <= 0 {
+			return ErrInvalidRateLimit
+		}
+
+		conf.rateLimit = bytesPerSecond
+		conf.rateBurst = burst
+
+		return nil
+	})
+}
+
+// SummaryInterval set minimal interval between summary entries reporting how many entries were suppressed by
+// sampling and rate limit. Summary is written when interval elapses after suppression, on Sync and on Close.
+func SummaryInterval(value time.Duration) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if value <= 0 {
+			return ErrInvalidSummaryInterval
+		}
+
+		conf.summaryInterval = value
+
+		return nil
+	})
+}
+
+// newSampler create sampler writing summary entries with write, returns nil when neither sampling nor rate limit is set.
+func newSampler(conf *optionConf, write func(zapcore.Entry, []zapcore.Field) error, limiter *rateLimiter) *sampler {
+	if len(conf.sampling) == 0 && limiter == nil {
+		return nil
+	}
+
+	var s = &sampler{
+		write:    write,
+		levels:   make(map[zapcore.Level]*levelSampler, len(conf.sampling)),
+		limiter:  limiter,
+		interval: conf.summaryInterval,
+	}
+
+	for level, sc := range conf.sampling {
+		s.levels[level] = &levelSampler{samplingConf: sc}
+	}
+
+	s.nextSummary.Store(time.Now().Add(s.interval).UnixNano())
+
+	if limiter != nil {
+		limiter.onDrop = s.schedule
+	}
+
+	return s
+}
+
+// newRateLimiter create rate limiter, returns nil when rate limit is not set.
+func newRateLimiter(conf *optionConf) *rateLimiter {
+	if conf.rateLimit <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{
+		rate:   float64(conf.rateLimit),
+		burst:  float64(conf.rateBurst),
+		tokens: float64(conf.rateBurst),
+		last:   time.Now(),
+	}
+}
+
+// allow returns true when entry should be logged.
+func (s *sampler) allow(e zapcore.Entry) bool {
+	var ls, ok = s.levels[e.Level]
+	if !ok {
+		return true
+	}
+
+	var n = ls.counters[messageSlot(e.Message)].incCheckReset(e.Time, ls.tick)
+	if n <= ls.first || (ls.thereafter > 0 && (n-ls.first)%ls.thereafter == 0) {
+		return true
+	}
+
+	s.sampled.Inc()
+	s.schedule()
+
+	return false
+}
+
+// schedule arms summary timer, so summary is written when summary interval elapses even if nothing is logged after.
+func (s *sampler) schedule() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timer != nil || s.closed {
+		return
+	}
+
+	s.pending.Add(1)
+	s.timer = time.AfterFunc(time.Duration(s.nextSummary.Load()-time.Now().UnixNano()), func() {
+		defer s.pending.Done()
+
+		s.mu.Lock()
+		s.timer = nil
+		s.mu.Unlock()
+
+		_ = s.summarize(time.Now(), false)
+		if s.suppressed() {
+			// summary was written by Sync meanwhile, entries suppressed after it wait for the next interval
+			s.schedule()
+		}
+	})
+}
+
+// suppressed returns true when there are suppressed entries not reported by summary yet.
+func (s *sampler) suppressed() bool {
+	return s.sampled.Load() > 0 || (s.limiter != nil && s.limiter.dropped.Load() > 0)
+}
+
+// flush stops summary timer and writes summary entry when entries were suppressed.
+func (s *sampler) flush() error {
+	s.mu.Lock()
+	if s.timer != nil && s.timer.Stop() {
+		s.timer = nil
+		s.pending.Done()
+	}
+	s.mu.Unlock()
+
+	return s.summarize(time.Now(), true)
+}
+
+// close stops scheduling of summary timer, waits for running summary and writes the last summary.
+func (s *sampler) close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	var err = s.flush()
+	s.pending.Wait()
+
+	return err
+}
+
+// summarize write summary entry with count of suppressed entries when summary interval elapsed or force is true.
+func (s *sampler) summarize(now time.Time, force bool) error {
+	var next = s.nextSummary.Load()
+	if !force && now.UnixNano() < next {
+		return nil
+	}
+
+	if !s.nextSummary.CAS(next, now.Add(s.interval).UnixNano()) {
+		return nil
+	}
+
+	var sampled, rateLimited = s.sampled.Swap(0), uint64(0)
+	if s.limiter != nil {
+		rateLimited = s.limiter.dropped.Swap(0)
+	}
+
+	if sampled == 0 && rateLimited == 0 {
+		return nil
+	}
+
+	return s.write(zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    now,
+		Message: summaryMessage,
+	}, []zapcore.Field{
+		zap.Uint64("_suppressed", sampled+rateLimited),
+		zap.Uint64("_suppressed_sampling", sampled),
+		zap.Uint64("_suppressed_rate_limit", rateLimited),
+	})
+}
+
+// incCheckReset increment counter, resets it when tick elapsed.
+func (c *samplingCounter) incCheckReset(t time.Time, tick time.Duration) uint64 {
+	var (
+		tn      = t.UnixNano()
+		resetAt = c.resetAt.Load()
+	)
+
+	if resetAt > tn {
+		return c.counter.Inc()
+	}
+
+	c.counter.Store(1)
+
+	var newResetAt = tn + tick.Nanoseconds()
+	if !c.resetAt.CAS(resetAt, newResetAt) {
+		// We raced with another goroutine trying to reset, and it also reset
+		// the counter to 1, so we need to reincrement the counter.
+		return c.counter.Inc()
+	}
+
+	return 1
+}
+
+// allow returns true when n bytes can be sent now.
+func (l *rateLimiter) allow(n int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+
+	l.last = now
+
+	if float64(n) > l.tokens {
+		l.dropped.Inc()
+		if l.onDrop != nil {
+			l.onDrop()
+		}
+
+		return false
+	}
+
+	l.tokens -= float64(n)
+
+	return true
+}
+
+// messageSlot returns sampling counter index of message.
+func messageSlot(message string) uint32 {
+	// Inline FNV-32a to avoid allocations.
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+
+	var hash = uint32(offset32)
+	for i := 0; i < len(message); i++ {
+		hash ^= uint32(message[i])
+		hash *= prime32
+	}
+
+	return hash % samplingCounters
+}
@@ -0,0 +1,135 @@
+package gelf_test
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestSampling(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.CompressionType(gelf.CompressionNone),
+		gelf.Sampling(zap.InfoLevel, time.Minute, 2, 3),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	for i := 0; i < 10; i++ {
+		logger.Info("sampled")
+		logger.Warn("not sampled")
+	}
+
+	assert.Nil(t, logger.Sync(), "Unexpected error")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(15, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Len(t, messages, 15)
+
+	var counts = make(map[interface{}]int)
+	for _, message := range messages {
+		counts[message["short_message"]]++
+	}
+
+	assert.Equal(t, 4, counts["sampled"])
+	assert.Equal(t, 10, counts["not sampled"])
+
+	var summary = messages[len(messages)-1]
+	assert.Equal(t, "gelf: entries suppressed", summary["short_message"])
+	assert.Equal(t, float64(6), summary["_suppressed"])
+	assert.Equal(t, float64(6), summary["_suppressed_sampling"])
+	assert.Equal(t, float64(0), summary["_suppressed_rate_limit"])
+}
+
+func TestRateLimit(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.CompressionType(gelf.CompressionNone),
+		gelf.RateLimit(1, 1000),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	for i := 0; i < 20; i++ {
+		logger.Info("rate limited")
+	}
+
+	var stats = core.(interface{ Stats() gelf.Stats }).Stats()
+	assert.Equal(t, uint64(20), stats.Entries)
+	assert.True(t, stats.Dropped > 0, "Expect dropped messages")
+
+	var messages, _ = server.Wait(20, 100*time.Millisecond)
+	assert.Equal(t, 20-int(stats.Dropped), len(messages))
+}
+
+func TestSamplingInvalid(t *testing.T) {
+	var options = []gelf.Option{
+		gelf.Sampling(zap.InfoLevel, 0, 1, 1),
+		gelf.Sampling(zap.InfoLevel, time.Second, -1, 1),
+		gelf.RateLimit(0, 1),
+		gelf.RateLimit(1, 0),
+		gelf.SummaryInterval(0),
+	}
+
+	for _, option := range options {
+		var core, err = gelf.NewCore(option)
+		assert.NotNil(t, err, "Expected error")
+		assert.Nil(t, core, "Expected nil")
+	}
+}
+
+func TestSamplingSummary(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.Sampling(zap.InfoLevel, time.Minute, 1, 0),
+		gelf.SummaryInterval(50*time.Millisecond),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	for i := 0; i < 5; i++ {
+		logger.Info("burst")
+	}
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "gelf: entries suppressed", messages[1]["short_message"])
+	assert.Equal(t, float64(4), messages[1]["_suppressed_sampling"])
+
+	var stats = core.(interface{ Stats() gelf.Stats }).Stats()
+	assert.Equal(t, uint64(2), stats.Entries)
+
+	server.Reset()
+
+	core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.Sampling(zap.InfoLevel, time.Minute, 1, 0),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	logger = zap.New(core)
+	logger.Info("burst")
+	logger.Info("burst")
+	assert.Nil(t, core.(io.Closer).Close(), "Unexpected error")
+
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, float64(1), messages[1]["_suppressed"])
+}
@@ -0,0 +1,150 @@
+package gelf
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// SinkScheme zap sink URL scheme, see zap.RegisterSink.
+	SinkScheme = "gelf"
+
+	// SinkSchemeUDP zap sink URL scheme with explicit UDP transport, see zap.RegisterSink.
+	SinkSchemeUDP = "gelf+udp"
+
+	// SinkSchemeTCP zap sink URL scheme with TCP transport, see zap.RegisterSink.
+	SinkSchemeTCP = "gelf+tcp"
+
+	// SinkSchemeTLS zap sink URL scheme with TLS transport, see zap.RegisterSink.
+	SinkSchemeTLS = "gelf+tls"
+
+	// SinkSchemeHTTP zap sink URL scheme with HTTP transport, see zap.RegisterSink.
+	SinkSchemeHTTP = "gelf+http"
+
+	// SinkSchemeHTTPS zap sink URL scheme with HTTP transport over TLS, see zap.RegisterSink.
+	SinkSchemeHTTPS = "gelf+https"
+
+	// defaultPort is default GELF input port.
+	defaultPort = "12201"
+
+	// defaultPath is default GELF HTTP input path.
+	defaultPath = "/gelf"
+)
+
+var (
+	// Ensure *writer implements zap.Sink.
+	_ zap.Sink = (*writer)(nil)
+
+	// sinkTransports maps sink URL schemes onto transports.
+	sinkTransports = map[string]string{
+		SinkScheme:      TransportUDP,
+		SinkSchemeUDP:   TransportUDP,
+		SinkSchemeTCP:   TransportTCP,
+		SinkSchemeTLS:   TransportTLS,
+		SinkSchemeHTTP:  TransportHTTP,
+		SinkSchemeHTTPS: TransportHTTP,
+	}
+
+	// compressionTypes maps sink URL compression names onto compression types.
+	compressionTypes = map[string]int{
+		"none": CompressionNone,
+		"gzip": CompressionGzip,
+		"zlib": CompressionZlib,
+	}
+)
+
+func init() {
+	for scheme := range sinkTransports {
+		if err := zap.RegisterSink(scheme, newSink); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// newSink zap sink constructor.
+//
+// URL format is gelf+udp://host[:port][?compress=none|gzip|zlib&compress_level=N&chunk=N], schemes gelf+tcp,
+// gelf+tls, gelf+http and gelf+https select other transports, so zap.Config can send logs to Graylog with:
+//
+//	OutputPaths: []string{"gelf+udp://graylog:12201?compress=zlib&chunk=8192"}
+//
+// Sink only transports already encoded entries, use NewEncoderConfig as zap.Config EncoderConfig
+// and zap.Config InitialFields for host and version to produce valid GELF messages.
+func newSink(u *url.URL) (_ zap.Sink, err error) {
+	var options []Option
+	if options, err = sinkOptions(u); err != nil {
+		return nil, err
+	}
+
+	var conf = newOptionConf()
+	for _, option := range options {
+		if err = option.apply(&conf); err != nil {
+			return nil, err
+		}
+	}
+
+	return newWriter(&conf)
+}
+
+// sinkOptions maps sink URL onto options.
+func sinkOptions(u *url.URL) (_ []Option, err error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("gelf sink: empty host in %q", u.String())
+	}
+
+	var addr = u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), defaultPort)
+	}
+
+	var transport = sinkTransports[u.Scheme]
+	if transport == TransportHTTP {
+		var scheme, path = "http", u.Path
+		if u.Scheme == SinkSchemeHTTPS {
+			scheme = "https"
+		}
+
+		if path == "" {
+			path = defaultPath
+		}
+
+		addr = scheme + "://" + addr + path
+	}
+
+	var options = []Option{Addr(addr), Transport(transport)}
+	for key, values := range u.Query() {
+		var value = values[len(values)-1]
+		switch key {
+		case "compress":
+			var compressionType, ok = compressionTypes[strings.ToLower(value)]
+			if !ok {
+				return nil, ErrUnknownCompressionType
+			}
+
+			options = append(options, CompressionType(compressionType))
+		case "compress_level":
+			var level int
+			if level, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("gelf sink: invalid compress_level %q", value)
+			}
+
+			options = append(options, CompressionLevel(level))
+		case "chunk":
+			var size int
+			if size, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("gelf sink: invalid chunk %q", value)
+			}
+
+			options = append(options, ChunkSize(size))
+		default:
+			return nil, fmt.Errorf("gelf sink: unknown parameter %q", key)
+		}
+	}
+
+	return options, nil
+}
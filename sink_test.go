@@ -0,0 +1,54 @@
+package gelf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestSink(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var conf = zap.NewProductionConfig()
+	conf.Sampling = nil
+	conf.EncoderConfig = gelf.NewEncoderConfig()
+	conf.InitialFields = map[string]interface{}{"host": "localhost", "version": "1.1"}
+	conf.OutputPaths = []string{"gelf+udp://" + server.UDPAddr() + "?compress=none&chunk=8192"}
+
+	var logger, err = conf.Build()
+	assert.Nil(t, err, "Unexpected error")
+
+	logger.Info("hello", zap.String("_key", "value"))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Equal(t, "hello", message["short_message"])
+	assert.Equal(t, "value", message["_key"])
+	assert.Equal(t, "1.1", message["version"])
+	assert.Equal(t, float64(6), message["level"])
+}
+
+func TestSinkInvalidURL(t *testing.T) {
+	var urls = []string{
+		"gelf://",
+		"gelf://127.0.0.1?compress=lz4",
+		"gelf://127.0.0.1?chunk=large",
+		"gelf://127.0.0.1?chunk=100",
+		"gelf://127.0.0.1?compress_level=best",
+		"gelf://127.0.0.1?unknown=1",
+	}
+
+	for _, u := range urls {
+		var _, _, err = zap.Open(u)
+		assert.NotNil(t, err, "Expected error for %s", u)
+	}
+}
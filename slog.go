@@ -0,0 +1,225 @@
+//go:build go1.21
+// +build go1.21
+
+package gelf
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type (
+	// Handler implements slog.Handler, records are encoded, escaped, compressed and sent as core entries.
+	//
+	// Attributes of groups are flattened to keys joined by dot, e.g. _request.method, trace context fields are extracted
+	// from context passed to Handle by TraceExtractors:
+	//
+	//	handler, err := gelf.NewHandler(gelf.Addr("graylog:12201"))
+	//	...
+	//	slog.New(handler).WithGroup("request").Info("handled", "method", "GET")
+	Handler struct {
+		core      *wrappedCore
+		untimed   *wrappedCore
+		prefix    string
+		addSource bool
+	}
+)
+
+var (
+	// Ensure *Handler implements slog.Handler.
+	_ slog.Handler = (*Handler)(nil)
+)
+
+// NewHandler slog.Handler constructor.
+func NewHandler(options ...Option) (_ *Handler, err error) {
+	var conf = newOptionConf()
+	for _, option := range options {
+		if err = option.apply(&conf); err != nil {
+			return nil, err
+		}
+	}
+
+	var w *writer
+	if w, err = newWriter(&conf); err != nil {
+		return nil, err
+	}
+
+	// slog.Handler should not output time of records with zero time
+	var untimedConf = conf
+	untimedConf.encoder.TimeKey = ""
+
+	var h = &Handler{
+		core:      newWrappedCore(&conf, w),
+		untimed:   newWrappedCore(&untimedConf, w),
+		addSource: conf.addSource,
+	}
+
+	// sampling and dedupe state is shared, summaries are written by timed core
+	h.untimed.sampler = h.core.sampler
+	h.untimed.dedupe = h.core.dedupe
+	if w.limiter != nil {
+		w.limiter.onDrop = h.core.sampler.schedule
+	}
+
+	return h, nil
+}
+
+// AddSource set adding of record source as _caller field by Handler.
+func AddSource(value bool) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.addSource = value
+		return nil
+	})
+}
+
+// Enabled implementation of slog.Handler.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(slogLevel(level))
+}
+
+// Handle implementation of slog.Handler.
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	var (
+		core  = h.core
+		entry = zapcore.Entry{
+			Level:   slogLevel(record.Level),
+			Time:    record.Time,
+			Message: record.Message,
+		}
+	)
+
+	if record.Time.IsZero() {
+		core = h.untimed
+	}
+
+	if h.addSource && record.PC != 0 {
+		var frame, _ = runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		entry.Caller.Function = frame.Function
+	}
+
+	if !core.allow(entry) {
+		return nil
+	}
+
+	var fields = make([]zapcore.Field, 0, record.NumAttrs()+1)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.prefix, attr)
+		return true
+	})
+
+	// trace context fields are extracted from context of record
+	fields = append(fields, Context(ctx))
+
+	return core.Write(entry, fields)
+}
+
+// WithAttrs implementation of slog.Handler.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields = make([]zapcore.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, h.prefix, attr)
+	}
+
+	if len(fields) == 0 {
+		return h
+	}
+
+	var clone = *h
+	clone.core = h.core.With(fields).(*wrappedCore)
+	clone.untimed = h.untimed.With(fields).(*wrappedCore)
+
+	return &clone
+}
+
+// WithGroup implementation of slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	var clone = *h
+	clone.prefix = h.prefix + name + "."
+
+	return &clone
+}
+
+// Sync flushes sampling summary and extra write syncers.
+func (h *Handler) Sync() error {
+	return h.core.Sync()
+}
+
+// Close writes pending summaries, stops summary timers and closes writer of the handler and all handlers derived from
+// it, neither of them may be used after close.
+func (h *Handler) Close() error {
+	return h.core.Close()
+}
+
+// Stats returns delivery statistics of the handler and all handlers derived from it.
+func (h *Handler) Stats() Stats {
+	return h.core.Stats()
+}
+
+// appendSlogAttr appends attribute as fields, group attributes are flattened.
+func appendSlogAttr(fields []zapcore.Field, prefix string, attr slog.Attr) []zapcore.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	var (
+		key   = prefix + attr.Key
+		value = attr.Value
+	)
+
+	switch value.Kind() {
+	case slog.KindGroup:
+		if attr.Key != "" {
+			prefix = key + "."
+		}
+
+		for _, groupAttr := range value.Group() {
+			fields = appendSlogAttr(fields, prefix, groupAttr)
+		}
+
+		return fields
+	case slog.KindString:
+		return append(fields, zap.String(key, value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(key, value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(key, value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(key, value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(key, value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(key, value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(key, value.Time()))
+	}
+
+	if err, ok := value.Any().(error); ok {
+		return append(fields, zap.NamedError(key, err))
+	}
+
+	return append(fields, zap.Any(key, value.Any()))
+}
+
+// slogLevel maps slog level onto zap level.
+func slogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
@@ -0,0 +1,171 @@
+//go:build go1.21
+// +build go1.21
+
+package gelf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestHandler(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var buf bytes.Buffer
+	var handler, err = gelf.NewHandler(
+		gelf.Addr(server.UDPAddr()),
+		gelf.WriteSyncers(zapcore.AddSync(&buf)),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	err = slogtest.TestHandler(handler, func() []map[string]interface{} {
+		var results []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var message map[string]interface{}
+			assert.Nil(t, json.Unmarshal([]byte(line), &message), "Unexpected error")
+			results = append(results, unflatten(message))
+		}
+
+		return results
+	})
+	assert.Nil(t, err, "Unexpected error")
+}
+
+func TestHandlerWrite(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var handler, err = gelf.NewHandler(
+		gelf.Addr(server.UDPAddr()),
+		gelf.Host("example.org"),
+		gelf.Level(zapcore.InfoLevel),
+		gelf.AddSource(true),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = slog.New(handler).With("id", "an_id").WithGroup("request")
+	logger.Debug("skipped")
+	logger.ErrorContext(
+		gelf.WithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"),
+		"failed", "method", "GET", slog.Group("user", "name", "alice"), "err", errors.New("boom"),
+	)
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Equal(t, "example.org", message["host"])
+	assert.Equal(t, "failed", message["short_message"])
+	assert.Equal(t, float64(3), message["level"])
+	assert.Equal(t, "an_id", message["__id"])
+	assert.Equal(t, "GET", message["_request.method"])
+	assert.Equal(t, "alice", message["_request.user.name"])
+	assert.Equal(t, "boom", message["_request.err"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", message["_trace_id"])
+	assert.Contains(t, message["_caller"], "slog_test.go")
+	assert.Equal(t, uint64(1), handler.Stats().Entries)
+}
+
+// unflatten maps GELF message onto slog keys and nested groups.
+func unflatten(message map[string]interface{}) map[string]interface{} {
+	var result = make(map[string]interface{}, len(message))
+	for key, value := range message {
+		switch key {
+		case "host", "version":
+			continue
+		case "short_message":
+			key = slog.MessageKey
+		case "timestamp":
+			key = slog.TimeKey
+		case "level":
+			key = slog.LevelKey
+		default:
+			key = strings.TrimPrefix(key, "_")
+		}
+
+		var (
+			group = result
+			path  = strings.Split(key, ".")
+		)
+
+		for _, name := range path[:len(path)-1] {
+			if _, ok := group[name].(map[string]interface{}); !ok {
+				group[name] = make(map[string]interface{})
+			}
+
+			group = group[name].(map[string]interface{})
+		}
+
+		group[path[len(path)-1]] = value
+	}
+
+	return result
+}
+
+func TestHandlerDeduplicate(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var handler, err = gelf.NewHandler(gelf.Addr(server.UDPAddr()), gelf.Deduplicate(time.Hour))
+	assert.Nil(t, err, "Unexpected error")
+
+	var ctx = context.Background()
+	assert.Nil(t, handler.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelError, "retry", 0)), "Unexpected error")
+	assert.Nil(t, handler.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelError, "retry", 0)), "Unexpected error")
+	assert.Nil(t, handler.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelError, "retry", 0)), "Unexpected error")
+	assert.Nil(t, handler.Sync(), "Unexpected error")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, 2.0, messages[1][gelf.RepeatCountKey])
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, server.Messages(), 2)
+}
+
+func TestHandlerRateLimit(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var handler, err = gelf.NewHandler(
+		gelf.Addr(server.UDPAddr()),
+		gelf.CompressionType(gelf.CompressionNone),
+		gelf.RateLimit(1000, 300),
+		gelf.SummaryInterval(300*time.Millisecond),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var ctx = context.Background()
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, handler.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "limited", 0)), "Unexpected error")
+	}
+
+	var dropped = handler.Stats().Dropped
+	assert.True(t, dropped > 0, "Expect dropped messages")
+
+	var messages, _ = server.Wait(10-int(dropped)+1, time.Second)
+	assert.Len(t, messages, 10-int(dropped)+1)
+
+	var summary = messages[len(messages)-1]
+	assert.Equal(t, "gelf: entries suppressed", summary["short_message"])
+	assert.Equal(t, float64(dropped), summary["_suppressed_rate_limit"])
+	assert.Contains(t, summary, "timestamp")
+
+	assert.Nil(t, handler.Close(), "Unexpected error")
+}
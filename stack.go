@@ -0,0 +1,203 @@
+package gelf
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"text/template"
+)
+
+const (
+	// fullMessageKey GELF full message key.
+	fullMessageKey = "full_message"
+
+	// DefaultFullMessageTemplate full message template with message, error and stack trace separated by empty lines.
+	DefaultFullMessageTemplate = "{{.Message}}{{if .Error}}\n\n{{.Error}}{{end}}{{if .Stack}}\n\n{{.Stack}}{{end}}"
+)
+
+type (
+	// FullMessage data of full message template.
+	FullMessage struct {
+		// Message entry message.
+		Message string
+
+		// Error message of the first error field passed to Write.
+		Error string
+
+		// Stack formatted stack trace, empty when entry has no stack trace.
+		Stack string
+	}
+
+	// stackConf stack trace formatting options.
+	stackConf struct {
+		trim     []string
+		depth    int
+		compact  bool
+		template *template.Template
+	}
+
+	// stackFrame stack trace frame.
+	stackFrame struct {
+		function string
+		location string
+	}
+)
+
+var (
+	// ErrInvalidStacktraceDepth triggered when passed invalid stack trace depth.
+	ErrInvalidStacktraceDepth = errors.New("invalid stacktrace depth")
+
+	// ErrInvalidFullMessageTemplate triggered when passed invalid full message template.
+	ErrInvalidFullMessageTemplate = errors.New("invalid full message template")
+
+	// legacyFullMessageTemplate full message template of GELF 1.0 mode.
+	legacyFullMessageTemplate = template.Must(template.New(fullMessageKey).Parse(DefaultFullMessageTemplate))
+
+	// loggerFramePrefixes function prefixes of runtime and logger frames.
+	loggerFramePrefixes = []string{
+		"runtime.",
+		"go.uber.org/zap.",
+		"go.uber.org/zap/",
+		"github.com/snovichkov/zap-gelf.",
+		"github.com/snovichkov/zap-gelf/",
+	}
+)
+
+// TrimStacktrace set trimming of runtime, zap and zap-gelf frames and frames of functions with given prefixes from
+// stack traces.
+func TrimStacktrace(prefixes ...string) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.stack.trim = append(append(conf.stack.trim[:0:0], loggerFramePrefixes...), prefixes...)
+		return nil
+	})
+}
+
+// StacktraceDepth set maximal count of stack trace frames, frames are counted after trimming.
+func StacktraceDepth(value int) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if value <= 0 {
+			return ErrInvalidStacktraceDepth
+		}
+
+		conf.stack.depth = value
+
+		return nil
+	})
+}
+
+// CompactStacktrace set rendering of stack traces in one line, e.g. main.handle (main.go:42) | main.main (main.go:10).
+func CompactStacktrace(value bool) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.stack.compact = value
+		return nil
+	})
+}
+
+// FullMessageTemplate set text/template of full_message executed with FullMessage, e.g. DefaultFullMessageTemplate.
+//
+// Stack trace is sent only as part of full message when StacktraceKey is full_message, use StacktraceKey("stacktrace")
+// to send it as _stacktrace as well.
+func FullMessageTemplate(value string) Option {
+	return optionFunc(func(conf *optionConf) error {
+		var tmpl, err = template.New(fullMessageKey).Parse(value)
+		if err == nil {
+			err = tmpl.Execute(ioutil.Discard, FullMessage{})
+		}
+
+		if err != nil {
+			return ErrInvalidFullMessageTemplate
+		}
+
+		conf.stack.template = tmpl
+
+		return nil
+	})
+}
+
+// formatted returns true when stack traces are trimmed, capped or compacted.
+func (sc *stackConf) formatted() bool {
+	return len(sc.trim) > 0 || sc.depth > 0 || sc.compact
+}
+
+// format returns stack trace of zap format formatted by options.
+func (sc *stackConf) format(stack string) string {
+	if stack == "" || !sc.formatted() {
+		return stack
+	}
+
+	var frames = parseStack(stack)
+	if len(sc.trim) > 0 {
+		var trimmed = frames[:0]
+		for _, frame := range frames {
+			if !hasAnyPrefix(frame.function, sc.trim) {
+				trimmed = append(trimmed, frame)
+			}
+		}
+
+		frames = trimmed
+	}
+
+	if sc.depth > 0 && len(frames) > sc.depth {
+		frames = frames[:sc.depth]
+	}
+
+	var b strings.Builder
+	for i, frame := range frames {
+		switch {
+		case sc.compact && i > 0:
+			b.WriteString(" | ")
+		case !sc.compact && i > 0:
+			b.WriteByte('\n')
+		}
+
+		b.WriteString(frame.function)
+		if sc.compact {
+			b.WriteString(" (" + frame.location + ")")
+		} else {
+			b.WriteString("\n\t" + frame.location)
+		}
+	}
+
+	return b.String()
+}
+
+// fullMessageTemplate returns template of full message, in GELF 1.0 mode full message is rendered by
+// DefaultFullMessageTemplate unless FullMessageTemplate is set.
+func (conf *optionConf) fullMessageTemplate() *template.Template {
+	if conf.stack.template == nil && conf.legacy() {
+		return legacyFullMessageTemplate
+	}
+
+	return conf.stack.template
+}
+
+// parseStack parses zap stack trace, each frame is function line followed by tab indented file:line line.
+func parseStack(stack string) []stackFrame {
+	var (
+		lines  = strings.Split(stack, "\n")
+		frames = make([]stackFrame, 0, len(lines)/2)
+	)
+
+	for i := 0; i < len(lines); i++ {
+		var frame = stackFrame{function: lines[i]}
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") {
+			frame.location = strings.TrimPrefix(lines[i+1], "\t")
+			i++
+		}
+
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+// hasAnyPrefix returns true when s has any of prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
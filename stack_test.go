@@ -0,0 +1,75 @@
+package gelf_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestStacktrace(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(gelf.Addr(server.UDPAddr()), gelf.TrimStacktrace("testing."))
+	assert.Nil(t, err, "Unexpected error")
+	zap.New(core, zap.AddStacktrace(zap.ErrorLevel)).Error("trimmed")
+
+	core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.TrimStacktrace(),
+		gelf.StacktraceDepth(1),
+		gelf.CompactStacktrace(true),
+	)
+	assert.Nil(t, err, "Unexpected error")
+	zap.New(core, zap.AddStacktrace(zap.ErrorLevel)).Error("compact")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var stack = messages[0]["full_message"]
+	assert.Regexp(t, `^github.com/snovichkov/zap-gelf_test\.TestStacktrace\n\t.+stack_test\.go:\d+$`, stack)
+
+	stack = messages[1]["full_message"]
+	assert.Regexp(t, `^github.com/snovichkov/zap-gelf_test\.TestStacktrace \(.+stack_test\.go:\d+\)$`, stack)
+
+	core, err = gelf.NewCore(gelf.StacktraceDepth(0))
+	assert.Equal(t, gelf.ErrInvalidStacktraceDepth, err)
+	assert.Nil(t, core, "Expected nil")
+}
+
+func TestFullMessageTemplate(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.FullMessageTemplate(gelf.DefaultFullMessageTemplate),
+		gelf.StacktraceKey("stacktrace"),
+		gelf.TrimStacktrace("testing."),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core, zap.AddStacktrace(zap.ErrorLevel))
+	logger.Error("failed", zap.Error(errors.New("timeout")))
+	logger.Info("done")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	var message = messages[0]
+	assert.Regexp(t, `^github.com/snovichkov/zap-gelf_test\.TestFullMessageTemplate\n\t.+:\d+$`, message["_stacktrace"])
+	assert.Equal(t, "failed\n\ntimeout\n\n"+message["_stacktrace"].(string), message["full_message"])
+	assert.Equal(t, "done", messages[1]["full_message"])
+
+	core, err = gelf.NewCore(gelf.FullMessageTemplate("{{.Unknown}}"))
+	assert.Equal(t, gelf.ErrInvalidFullMessageTemplate, err)
+	assert.Nil(t, core, "Expected nil")
+}
@@ -0,0 +1,97 @@
+package gelf
+
+import (
+	"go.uber.org/atomic"
+)
+
+type (
+	// Stats is a snapshot of core delivery statistics.
+	//
+	// Core returned by NewCore implements interface{ Stats() Stats }, it is safe to call concurrently with logging,
+	// for example to publish statistics with expvar:
+	//
+	//	expvar.Publish("gelf", expvar.Func(func() interface{} {
+	//		return core.(interface{ Stats() gelf.Stats }).Stats()
+	//	}))
+	Stats struct {
+		// Entries count of entries written without error, including messages dropped by rate limit. Messages dropped
+		// for needing more than MaxChunkCount chunks fail with error, so they are counted in Dropped only.
+		Entries uint64
+
+		// Bytes count of encoded message bytes before compression.
+		Bytes uint64
+
+		// CompressedBytes count of message bytes after compression.
+		CompressedBytes uint64
+
+		// ChunkedMessages count of messages sent by chunks.
+		ChunkedMessages uint64
+
+		// Chunks count of sent chunks.
+		Chunks uint64
+
+		// Dropped count of messages dropped without sending, e.g. when message needs more than MaxChunkCount chunks.
+		Dropped uint64
+
+		// WriteErrors count of failed network writes.
+		WriteErrors uint64
+
+		// Reconnects count of TCP and TLS reconnects.
+		Reconnects uint64
+
+		// QueueDepth count of messages written to compression pipeline and not sent yet, see CompressWorkers.
+		QueueDepth uint64
+	}
+
+	// stats delivery counters, observes writer and cores.
+	stats struct {
+		entries         atomic.Uint64
+		bytes           atomic.Uint64
+		compressedBytes atomic.Uint64
+		chunkedMessages atomic.Uint64
+		chunks          atomic.Uint64
+		dropped         atomic.Uint64
+		writeErrors     atomic.Uint64
+		reconnects      atomic.Uint64
+		queueDepth      atomic.Uint64
+	}
+)
+
+// ObserveEntry implementation of Observer.
+func (s *stats) ObserveEntry(event EntryEvent) {
+	if event.Err == nil {
+		s.entries.Inc()
+	}
+}
+
+// ObserveMessage implementation of Observer.
+func (s *stats) ObserveMessage(event MessageEvent) {
+	s.bytes.Add(uint64(event.Size))
+	s.compressedBytes.Add(uint64(event.CompressedSize))
+	s.reconnects.Add(uint64(event.Reconnects))
+
+	switch {
+	case event.Dropped:
+		s.dropped.Inc()
+	case event.Err != nil:
+		s.writeErrors.Inc()
+	case event.Chunks > 1:
+		s.chunkedMessages.Inc()
+		s.chunks.Add(uint64(event.Chunks))
+	}
+}
+
+// snapshot returns current counters values.
+func (s *stats) snapshot() Stats {
+	return Stats{
+		Entries:         s.entries.Load(),
+		Bytes:           s.bytes.Load(),
+		CompressedBytes: s.compressedBytes.Load(),
+		ChunkedMessages: s.chunkedMessages.Load(),
+		Chunks:          s.chunks.Load(),
+		Dropped:         s.dropped.Load(),
+		WriteErrors:     s.writeErrors.Load(),
+		Reconnects:      s.reconnects.Load(),
+		QueueDepth:      s.queueDepth.Load(),
+	}
+}
@@ -0,0 +1,69 @@
+package gelf_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestStats(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.ChunkSize(gelf.MinChunkSize),
+		gelf.CompressionType(gelf.CompressionNone),
+		gelf.OnError(func(error, zapcore.Entry) {}),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var (
+		wg     sync.WaitGroup
+		done   = make(chan struct{})
+		stats  = core.(interface{ Stats() gelf.Stats })
+		logger = zap.New(core).With(zap.String("with", "field"))
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				stats.Stats()
+			}
+		}
+	}()
+
+	logger.Info("short")
+	logger.Info(strings.Repeat("x", gelf.MinChunkSize*2))
+	logger.Info(strings.Repeat("x", gelf.MinChunkSize*gelf.MaxChunkCount))
+
+	close(done)
+	wg.Wait()
+
+	var s = stats.Stats()
+	assert.Equal(t, uint64(2), s.Entries)
+	assert.Equal(t, uint64(1), s.ChunkedMessages)
+	assert.Equal(t, uint64(3), s.Chunks)
+	assert.Equal(t, uint64(1), s.Dropped)
+	assert.Equal(t, uint64(0), s.WriteErrors)
+	assert.Equal(t, s.Bytes, s.CompressedBytes)
+	assert.True(t, s.Bytes > uint64(gelf.MinChunkSize*gelf.MaxChunkCount))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Len(t, messages, 2)
+}
@@ -0,0 +1,167 @@
+package gelf
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// TraceIDKey trace ID field key.
+	TraceIDKey = "_trace_id"
+
+	// SpanIDKey span ID field key.
+	SpanIDKey = "_span_id"
+
+	// TraceFlagsKey trace flags field key.
+	TraceFlagsKey = "_trace_flags"
+)
+
+type (
+	// TraceContext trace correlation identifiers.
+	TraceContext struct {
+		// TraceID hex encoded 16 bytes trace ID.
+		TraceID string
+
+		// SpanID hex encoded 8 bytes span ID.
+		SpanID string
+
+		// Flags trace flags, 0x01 is sampled flag.
+		Flags byte
+	}
+
+	// TraceExtractor extracts trace context from context.Context.
+	TraceExtractor interface {
+		// Extract returns trace context, false when ctx is not traced.
+		Extract(ctx context.Context) (TraceContext, bool)
+	}
+
+	// TraceExtractorFunc wraps a func so it satisfies the TraceExtractor interface.
+	TraceExtractorFunc func(ctx context.Context) (TraceContext, bool)
+
+	// contextField value of field created by Context.
+	contextField struct {
+		ctx context.Context
+	}
+
+	// traceparentKey context key of W3C traceparent.
+	traceparentKey struct{}
+)
+
+// Context constructs a field with trace context of ctx, the field is expanded to _trace_id, _span_id and _trace_flags
+// fields by core with TraceExtractors, other cores skip it:
+//
+//	logger.Info("handled", gelf.Context(r.Context()))
+func Context(ctx context.Context) zap.Field {
+	return zap.Field{Type: zapcore.SkipType, Interface: contextField{ctx}}
+}
+
+// TraceExtractors set trace context extractors used by Context fields, first extracted trace context is used.
+// TraceparentExtractor is used by default.
+func TraceExtractors(value ...TraceExtractor) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.traceExtractors = value
+		return nil
+	})
+}
+
+// WithTraceparent returns copy of ctx with W3C traceparent header value.
+func WithTraceparent(ctx context.Context, traceparent string) context.Context {
+	return context.WithValue(ctx, traceparentKey{}, traceparent)
+}
+
+// TraceparentExtractor returns extractor of W3C traceparent set by WithTraceparent.
+func TraceparentExtractor() TraceExtractor {
+	return TraceExtractorFunc(func(ctx context.Context) (TraceContext, bool) {
+		var traceparent, _ = ctx.Value(traceparentKey{}).(string)
+		return ParseTraceparent(traceparent)
+	})
+}
+
+// ParseTraceparent parses W3C traceparent header value, returns false when value is invalid.
+// See https://www.w3.org/TR/trace-context/#traceparent-header.
+func ParseTraceparent(value string) (tc TraceContext, ok bool) {
+	// version-trace_id-span_id-flags, future versions may append fields
+	if len(value) < 55 || (len(value) > 55 && (value[:2] == "00" || value[55] != '-')) {
+		return tc, false
+	}
+
+	if value[2] != '-' || value[35] != '-' || value[52] != '-' || value[:2] == "ff" {
+		return tc, false
+	}
+
+	var version, flags []byte
+	if version = decodeHex(value[:2]); version == nil {
+		return tc, false
+	}
+
+	if flags = decodeHex(value[53:55]); flags == nil {
+		return tc, false
+	}
+
+	tc = TraceContext{
+		TraceID: value[3:35],
+		SpanID:  value[36:52],
+		Flags:   flags[0],
+	}
+
+	if !isValidID(tc.TraceID) || !isValidID(tc.SpanID) {
+		return TraceContext{}, false
+	}
+
+	return tc, true
+}
+
+// Extract implementation of TraceExtractor.
+func (f TraceExtractorFunc) Extract(ctx context.Context) (TraceContext, bool) {
+	return f(ctx)
+}
+
+// appendTrace appends trace fields of ctx extracted by first matched extractor.
+func appendTrace(fields []zapcore.Field, extractors []TraceExtractor, ctx context.Context) []zapcore.Field {
+	if ctx == nil {
+		return fields
+	}
+
+	for _, extractor := range extractors {
+		if tc, ok := extractor.Extract(ctx); ok {
+			return append(
+				fields,
+				zap.String(TraceIDKey, tc.TraceID),
+				zap.String(SpanIDKey, tc.SpanID),
+				zap.String(TraceFlagsKey, hex.EncodeToString([]byte{tc.Flags})),
+			)
+		}
+	}
+
+	return fields
+}
+
+// decodeHex decodes lowercase hex string, returns nil when value is invalid.
+func decodeHex(value string) []byte {
+	if strings.ToLower(value) != value {
+		return nil
+	}
+
+	var data, err = hex.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// isValidID returns true when value is lowercase hex string of not all zero bytes.
+func isValidID(value string) bool {
+	var data = decodeHex(value)
+	for _, b := range data {
+		if b != 0 {
+			return true
+		}
+	}
+
+	return false
+}
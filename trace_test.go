@@ -0,0 +1,98 @@
+package gelf_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+type (
+	// traceKey context key of custom trace ID.
+	traceKey struct{}
+)
+
+func TestParseTraceparent(t *testing.T) {
+	var tc, ok = gelf.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok, "Expected valid traceparent")
+	assert.Equal(t, gelf.TraceContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+		Flags:   1,
+	}, tc)
+
+	_, ok = gelf.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	assert.True(t, ok, "Expected valid traceparent")
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-zz",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01",
+	} {
+		_, ok = gelf.ParseTraceparent(value)
+		assert.False(t, ok, "Expected invalid traceparent %q", value)
+	}
+}
+
+func TestContext(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(gelf.Addr(server.UDPAddr()))
+	assert.Nil(t, err, "Unexpected error")
+
+	var (
+		logger = zap.New(core)
+		ctx    = gelf.WithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	)
+
+	logger.Info("traced", gelf.Context(ctx))
+	logger.With(gelf.Context(ctx)).Info("with")
+	logger.Info("not traced", gelf.Context(context.Background()))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(3, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	for _, message := range messages[:2] {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", message["_trace_id"])
+		assert.Equal(t, "00f067aa0ba902b7", message["_span_id"])
+		assert.Equal(t, "01", message["_trace_flags"])
+	}
+
+	assert.NotContains(t, messages[2], "_trace_id")
+}
+
+func TestTraceExtractors(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.UDPAddr()),
+		gelf.TraceExtractors(gelf.TraceExtractorFunc(func(ctx context.Context) (gelf.TraceContext, bool) {
+			var traceID, ok = ctx.Value(traceKey{}).(string)
+			return gelf.TraceContext{TraceID: traceID, SpanID: "span"}, ok
+		})),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var ctx = context.WithValue(context.Background(), traceKey{}, "custom")
+	zap.New(core).Info("custom", gelf.Context(ctx))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "custom", messages[0]["_trace_id"])
+	assert.Equal(t, "span", messages[0]["_span_id"])
+	assert.Equal(t, "00", messages[0]["_trace_flags"])
+}
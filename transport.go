@@ -0,0 +1,241 @@
+package gelf
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// TransportUDP send messages over UDP, messages are compressed and chunked.
+	TransportUDP = "udp"
+
+	// TransportTCP send null byte delimited messages over TCP, messages are not compressed nor chunked.
+	TransportTCP = "tcp"
+
+	// TransportTLS send null byte delimited messages over TCP with TLS, messages are not compressed nor chunked.
+	TransportTLS = "tls"
+
+	// TransportHTTP send messages by HTTP POST requests, messages are compressed and not chunked.
+	TransportHTTP = "http"
+
+	// DefaultTimeout is default dial, write and HTTP request timeout of TCP, TLS and HTTP transports.
+	DefaultTimeout = 5 * time.Second
+)
+
+type (
+	// sender sends compressed GELF messages over stream transports.
+	sender interface {
+		io.Closer
+
+		// send sends message, returns count of reconnects made while sending.
+		send(payload []byte) (reconnects int, err error)
+	}
+
+	// streamSender sends null byte delimited messages over TCP or TLS connection, reconnects on write errors.
+	// It is not safe for concurrent use, writer serializes sending and closing.
+	streamSender struct {
+		dial      func() (net.Conn, error)
+		conn      net.Conn
+		timeout   time.Duration
+		connected bool
+	}
+
+	// httpSender sends messages by HTTP POST requests.
+	httpSender struct {
+		url      string
+		encoding string
+		client   *http.Client
+	}
+)
+
+var (
+	// ErrUnknownTransport triggered when passed invalid transport.
+	ErrUnknownTransport = errors.New("unknown transport")
+
+	// ErrInvalidTimeout triggered when passed invalid timeout.
+	ErrInvalidTimeout = errors.New("invalid timeout")
+
+	// nullDelimiter delimiter of messages sent over stream transports.
+	nullDelimiter = []byte{0}
+)
+
+// Transport set GELF transport, TransportUDP by default.
+//
+// Compression is not used with TransportTCP and TransportTLS. With TransportHTTP Addr may be set to URL of GELF HTTP
+// input, otherwise http://<addr>/gelf is used. TCP and TLS connections are established on first write and
+// re-established after write errors.
+func Transport(value string) Option {
+	return optionFunc(func(conf *optionConf) error {
+		switch value {
+		case TransportUDP, TransportTCP, TransportTLS, TransportHTTP:
+		default:
+			return ErrUnknownTransport
+		}
+
+		conf.transport = value
+
+		return nil
+	})
+}
+
+// TLSConfig set TLS config of TransportTLS and HTTPS requests of TransportHTTP.
+func TLSConfig(value *tls.Config) Option {
+	return optionFunc(func(conf *optionConf) error {
+		conf.tlsConfig = value
+		return nil
+	})
+}
+
+// Timeout set dial, write and HTTP request timeout of TCP, TLS and HTTP transports.
+func Timeout(value time.Duration) Option {
+	return optionFunc(func(conf *optionConf) error {
+		if value <= 0 {
+			return ErrInvalidTimeout
+		}
+
+		conf.timeout = value
+
+		return nil
+	})
+}
+
+// newSender create sender of stream transport, returns nil for TransportUDP.
+func newSender(conf *optionConf) sender {
+	var dialer = &net.Dialer{Timeout: conf.timeout}
+	switch conf.transport {
+	case TransportTCP:
+		return &streamSender{
+			timeout: conf.timeout,
+			dial: func() (net.Conn, error) {
+				return dialer.Dial("tcp", conf.addr)
+			},
+		}
+	case TransportTLS:
+		var tlsConfig = conf.tlsConfig
+		return &streamSender{
+			timeout: conf.timeout,
+			dial: func() (net.Conn, error) {
+				return tls.DialWithDialer(dialer, "tcp", conf.addr, tlsConfig)
+			},
+		}
+	case TransportHTTP:
+		var s = &httpSender{
+			url: conf.addr,
+			client: &http.Client{
+				Timeout: conf.timeout,
+				Transport: &http.Transport{
+					Proxy:           http.ProxyFromEnvironment,
+					DialContext:     dialer.DialContext,
+					TLSClientConfig: conf.tlsConfig,
+				},
+			},
+		}
+
+		if !strings.Contains(s.url, "://") {
+			var scheme = "http"
+			if conf.tlsConfig != nil {
+				scheme = "https"
+			}
+
+			s.url = scheme + "://" + s.url + "/gelf"
+		}
+
+		switch conf.compressionType {
+		case CompressionGzip:
+			s.encoding = "gzip"
+		case CompressionZlib:
+			s.encoding = "deflate"
+		}
+
+		return s
+	}
+
+	return nil
+}
+
+// send implementation of sender.
+func (s *streamSender) send(payload []byte) (reconnects int, err error) {
+	// retry once on a new connection, previous one could be closed by server
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if s.conn, err = s.dial(); err != nil {
+				s.conn = nil
+				return reconnects, err
+			}
+
+			if s.connected {
+				reconnects++
+			}
+
+			s.connected = true
+		}
+
+		if err = s.conn.SetWriteDeadline(time.Now().Add(s.timeout)); err == nil {
+			// delimiter is written separately, payload may be shared with caller
+			var buffers = net.Buffers{payload, nullDelimiter}
+			_, err = buffers.WriteTo(s.conn)
+		}
+
+		if err == nil {
+			return reconnects, nil
+		}
+
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	return reconnects, err
+}
+
+// Close implementation of io.Closer.
+func (s *streamSender) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+
+	var err = s.conn.Close()
+	s.conn = nil
+
+	return err
+}
+
+// send implementation of sender.
+func (s *httpSender) send(payload []byte) (_ int, err error) {
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodPost, s.url, bytes.NewReader(payload)); err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if s.encoding != "" {
+		req.Header.Set("Content-Encoding", s.encoding)
+	}
+
+	var resp *http.Response
+	if resp, err = s.client.Do(req); err != nil {
+		return 0, err
+	}
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+
+	return 0, nil
+}
+
+// Close implementation of io.Closer.
+func (s *httpSender) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
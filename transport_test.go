@@ -0,0 +1,164 @@
+package gelf_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestTransportTCP(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.TCPAddr()),
+		gelf.Transport(gelf.TransportTCP),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var logger = zap.New(core)
+	logger.Info("first")
+	logger.Info(strings.Repeat("x", gelf.MaxChunkSize*2))
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "first", messages[0]["short_message"])
+	assert.Equal(t, strings.Repeat("x", gelf.MaxChunkSize*2), messages[1]["short_message"])
+	assert.Empty(t, server.Errors())
+}
+
+func TestTransportHTTP(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	for _, compressionType := range []int{gelf.CompressionNone, gelf.CompressionGzip, gelf.CompressionZlib} {
+		var core, err = gelf.NewCore(
+			gelf.Addr(server.HTTPURL()),
+			gelf.Transport(gelf.TransportHTTP),
+			gelf.CompressionType(compressionType),
+		)
+		assert.Nil(t, err, "Unexpected error")
+		assert.Nil(t, core.Write(zapcore.Entry{Message: "http"}, nil), "Unexpected error")
+	}
+
+	var messages, err = server.Wait(3, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Len(t, messages, 3)
+
+	var core zapcore.Core
+	core, err = gelf.NewCore(
+		gelf.Addr(strings.TrimSuffix(server.HTTPURL(), "/gelf")+"/unknown"),
+		gelf.Transport(gelf.TransportHTTP),
+		gelf.CompressionType(gelf.CompressionNone),
+	)
+	assert.Nil(t, err, "Unexpected error")
+	assert.NotNil(t, core.Write(zapcore.Entry{Message: "invalid"}, nil), "Expected error")
+}
+
+func TestTransportTLS(t *testing.T) {
+	var srv = httptest.NewUnstartedServer(nil)
+	srv.StartTLS()
+	defer srv.Close()
+
+	var listener, err = tls.Listen("tcp", "127.0.0.1:0", srv.TLS)
+	assert.Nil(t, err, "Unexpected error")
+	defer listener.Close()
+
+	var received = make(chan []byte, 1)
+	go func() {
+		var conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		var frame, _ = bufio.NewReader(conn).ReadBytes(0)
+		received <- frame
+	}()
+
+	var roots = x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	var core zapcore.Core
+	core, err = gelf.NewCore(
+		gelf.Addr(listener.Addr().String()),
+		gelf.Transport(gelf.TransportTLS),
+		gelf.TLSConfig(&tls.Config{RootCAs: roots, ServerName: "example.com"}),
+	)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Nil(t, core.Write(zapcore.Entry{Message: "tls"}, nil), "Unexpected error")
+
+	select {
+	case frame := <-received:
+		var message map[string]interface{}
+		assert.Nil(t, json.Unmarshal(bytes.TrimSuffix(frame, []byte{0}), &message), "Unexpected error")
+		assert.Equal(t, "tls", message["short_message"])
+	case <-time.After(time.Second):
+		t.Fatal("Message not received")
+	}
+}
+
+func TestTransportReconnect(t *testing.T) {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err, "Unexpected error")
+	defer listener.Close()
+
+	go func() {
+		for i := 0; ; i++ {
+			var conn, err = listener.Accept()
+			if err != nil {
+				return
+			}
+
+			if i == 0 {
+				// close first connection to force reconnect
+				conn.Close()
+				continue
+			}
+
+			defer conn.Close()
+		}
+	}()
+
+	var core zapcore.Core
+	core, err = gelf.NewCore(
+		gelf.Addr(listener.Addr().String()),
+		gelf.Transport(gelf.TransportTCP),
+		gelf.OnError(func(error, zapcore.Entry) {}),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var stats = core.(interface{ Stats() gelf.Stats })
+	for i := 0; i < 50 && stats.Stats().Reconnects == 0; i++ {
+		assert.Nil(t, core.Write(zapcore.Entry{Message: "reconnect"}, nil), "Unexpected error")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, uint64(1), stats.Stats().Reconnects)
+}
+
+func TestTransportInvalid(t *testing.T) {
+	var core, err = gelf.NewCore(gelf.Transport("quic"))
+	assert.Equal(t, gelf.ErrUnknownTransport, err)
+	assert.Nil(t, core, "Expected nil")
+
+	core, err = gelf.NewCore(gelf.Timeout(0))
+	assert.Equal(t, gelf.ErrInvalidTimeout, err)
+	assert.Nil(t, core, "Expected nil")
+}
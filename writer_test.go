@@ -0,0 +1,227 @@
+package gelf_test
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	gelf "github.com/snovichkov/zap-gelf"
+	"github.com/snovichkov/zap-gelf/gelftest"
+)
+
+func TestWriterConcurrent(t *testing.T) {
+	const goroutines = 8
+
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var transports = []struct {
+		name       string
+		perRoutine int
+		options    []gelf.Option
+	}{
+		// UDP burst is limited by socket receive buffer of server
+		{"udp", 5, []gelf.Option{gelf.Addr(server.UDPAddr()), gelf.ChunkSize(gelf.MinChunkSize)}},
+		{"tcp", 50, []gelf.Option{gelf.Addr(server.TCPAddr()), gelf.Transport(gelf.TransportTCP)}},
+		{"http", 50, []gelf.Option{gelf.Addr(server.HTTPURL()), gelf.Transport(gelf.TransportHTTP)}},
+	}
+
+	for _, tt := range transports {
+		for _, workers := range []int{0, 4} {
+			var perRoutine = tt.perRoutine
+			t.Run(fmt.Sprintf("%s/%d", tt.name, workers), func(t *testing.T) {
+				server.Reset()
+
+				var opts = append(tt.options[:len(tt.options):len(tt.options)], gelf.CompressionType(gelf.CompressionNone))
+				if workers > 0 {
+					opts = append(opts, gelf.CompressWorkers(workers))
+				}
+
+				var w, err = gelf.NewWriter(opts...)
+				assert.Nil(t, err, "Unexpected error")
+
+				var wg sync.WaitGroup
+				for i := 0; i < goroutines; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+
+						for j := 0; j < perRoutine; j++ {
+							var _, err = io.WriteString(w, testMessage(fmt.Sprintf("%d-%d", i, j), gelf.MinChunkSize*2))
+							assert.Nil(t, err, "Unexpected error")
+						}
+					}(i)
+				}
+
+				wg.Wait()
+				assert.Nil(t, w.Close(), "Unexpected error")
+
+				var messages []gelftest.Message
+				messages, err = server.Wait(goroutines*perRoutine, 5*time.Second)
+				assert.Nil(t, err, "Unexpected error")
+
+				var ids = make(map[interface{}]struct{}, len(messages))
+				for _, message := range messages {
+					ids[message["_n"]] = struct{}{}
+				}
+
+				assert.Len(t, ids, goroutines*perRoutine, "Expected unique messages")
+				assert.Empty(t, server.Errors(), "Unexpected error")
+			})
+		}
+	}
+}
+
+func TestCompressWorkers(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var w, err = gelf.NewWriter(
+		gelf.Addr(server.TCPAddr()),
+		gelf.Transport(gelf.TransportTCP),
+		gelf.CompressWorkers(4),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	for i := 0; i < 100; i++ {
+		_, err = io.WriteString(w, testMessage(fmt.Sprint(i), i*10))
+		assert.Nil(t, err, "Unexpected error")
+	}
+
+	assert.Nil(t, w.(interface{ Sync() error }).Sync(), "Unexpected error")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(100, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+
+	for i, message := range messages {
+		assert.Equal(t, fmt.Sprint(i), message["_n"], "Expected messages in order of writes")
+	}
+
+	assert.Nil(t, w.Close(), "Unexpected error")
+
+	_, err = io.WriteString(w, testMessage("closed", 0))
+	assert.Equal(t, gelf.ErrWriterClosed, err)
+
+	server.Reset()
+
+	var core zapcore.Core
+	core, err = gelf.NewCore(gelf.Addr(server.UDPAddr()), gelf.CompressWorkers(2))
+	assert.Nil(t, err, "Unexpected error")
+
+	zap.New(core).Info("queued")
+	assert.Nil(t, core.Sync(), "Unexpected error")
+
+	messages, err = server.Wait(1, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "queued", messages[0]["short_message"])
+
+	w, err = gelf.NewWriter(gelf.CompressWorkers(0))
+	assert.Equal(t, gelf.ErrInvalidCompressWorkers, err)
+	assert.Nil(t, w, "Expected nil")
+}
+
+func TestCompressWorkersErrors(t *testing.T) {
+	var release = make(chan struct{})
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var (
+		mu   sync.Mutex
+		errs []string
+	)
+
+	var core, err = gelf.NewCore(
+		gelf.Addr(server.URL),
+		gelf.Transport(gelf.TransportHTTP),
+		gelf.CompressWorkers(1),
+		gelf.OnError(func(err error, entry zapcore.Entry) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, entry.Message+": "+err.Error())
+		}),
+	)
+	assert.Nil(t, err, "Unexpected error")
+
+	var (
+		logger = zap.New(core)
+		stats  = core.(interface{ Stats() gelf.Stats })
+	)
+
+	logger.Info("first")
+	assert.Equal(t, uint64(1), stats.Stats().QueueDepth)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return stats.Stats().WriteErrors == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// delivery error of the first entry is returned by write of the next one
+	logger.Info("second")
+	mu.Lock()
+	assert.Equal(t, []string{"second: unexpected HTTP status 500"}, errs)
+	mu.Unlock()
+
+	assert.NotNil(t, core.Sync(), "Expected error")
+	assert.Equal(t, uint64(0), stats.Stats().QueueDepth)
+	assert.Nil(t, core.Sync(), "Unexpected error")
+}
+
+func TestWriterShortWrite(t *testing.T) {
+	var server = gelftest.NewServer()
+	defer server.Close()
+
+	var w, err = gelf.NewWriter(
+		gelf.Addr(server.UDPAddr()),
+		gelf.CompressionType(gelf.CompressionGzip),
+		gelf.ChunkSize(gelf.MinChunkSize),
+	)
+	assert.Nil(t, err, "Unexpected error")
+	defer w.Close()
+
+	var random = make([]byte, 2048)
+	_, _ = rand.New(rand.NewSource(1)).Read(random)
+
+	// chunked message
+	var (
+		message = `{"version":"1.1","host":"example.org","short_message":"` + hex.EncodeToString(random) + `","_n":"chunked"}`
+		n       int64
+	)
+
+	n, err = io.Copy(w, strings.NewReader(message))
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, int64(len(message)), n)
+
+	// single datagram
+	var bw = bufio.NewWriter(w)
+	_, err = bw.WriteString(testMessage("buffered", 100))
+	assert.Nil(t, err, "Unexpected error")
+	assert.Nil(t, bw.Flush(), "Unexpected error")
+
+	var messages []gelftest.Message
+	messages, err = server.Wait(2, time.Second)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "chunked", messages[0]["_n"])
+	assert.Equal(t, "buffered", messages[1]["_n"])
+}
+
+// testMessage returns GELF message with _n field and short message of padding length.
+func testMessage(id string, padding int) string {
+	return `{"version":"1.1","host":"example.org","short_message":"x` + strings.Repeat("x", padding) +
+		`","_n":"` + id + `"}`
+}